@@ -0,0 +1,193 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/fornellas/virginmedia_hub6_exporter/events"
+	"github.com/fornellas/virginmedia_hub6_exporter/exporter"
+	"github.com/fornellas/virginmedia_hub6_exporter/health"
+	"github.com/fornellas/virginmedia_hub6_exporter/plan"
+	"github.com/fornellas/virginmedia_hub6_exporter/store"
+)
+
+// passwordEnv is the environment variable from which the Hub admin password can be read,
+// instead of --password-file.
+const passwordEnv = "VM_HUB6_EXPORTER_PASSWORD"
+
+// addHubExporterFlags adds the flags used by newHubExporterFactory to cmd.
+func addHubExporterFlags(cmd *cobra.Command) {
+	cmd.Flags().String("health-thresholds-file", "", "Path of a JSON file overriding the default signal quality thresholds (see health.Thresholds)")
+	cmd.Flags().String("plan-file", "", "Path of a JSON file declaring the plan rates paid for by target, checked against the provisioned rates (see plan.Plans)")
+	cmd.Flags().String("state.dir", "", "Directory where state of derived metrics (eg: reboot counts) is persisted across restarts; if empty, state is not persisted")
+	cmd.Flags().Duration("state.flush-interval", time.Minute, "Interval at which state is written to --state.dir")
+	cmd.Flags().String("password-file", "", "Path of a file containing the Hub admin password, used to log in to reach password protected endpoints; alternatively, set it with the "+passwordEnv+" environment variable")
+	cmd.Flags().Bool("lan-host-info", false, "Export per host metrics (hostname, MAC, IP, Wi-Fi band and RSSI) of each host connected to the Hub LAN; requires logging in to the Hub")
+	cmd.Flags().Bool("eventlog.log", false, "Log each new cable modem event log entry")
+	addSyslogFlags(cmd)
+	addWebhookFlags(cmd)
+}
+
+// hubExporterFactory creates HubExporters configured from the flags added by
+// addHubExporterFlags.
+type hubExporterFactory struct {
+	healthThresholds health.Thresholds
+	plans            plan.Plans
+	// empty if not logging in to the Hub
+	password           string
+	lanHostInfo        bool
+	eventLogForwarding bool
+	// where detected events are sent to
+	eventSinks events.Sinks
+	// nil if state is not persisted
+	store *store.Store
+
+	mu sync.Mutex
+	// All created HubExporters, by target
+	hubExporters map[string]*exporter.HubExporter
+}
+
+// newHubExporterFactory reads the flags added by addHubExporterFlags, and creates a
+// hubExporterFactory from them. Close must be called when done.
+func newHubExporterFactory(cmd *cobra.Command) (*hubExporterFactory, error) {
+	healthThresholdsFile, err := cmd.Flags().GetString("health-thresholds-file")
+	if err != nil {
+		return nil, err
+	}
+	planFile, err := cmd.Flags().GetString("plan-file")
+	if err != nil {
+		return nil, err
+	}
+	stateDir, err := cmd.Flags().GetString("state.dir")
+	if err != nil {
+		return nil, err
+	}
+	stateFlushInterval, err := cmd.Flags().GetDuration("state.flush-interval")
+	if err != nil {
+		return nil, err
+	}
+	if stateFlushInterval <= 0 {
+		return nil, errors.New("--state.flush-interval must be positive")
+	}
+	passwordFile, err := cmd.Flags().GetString("password-file")
+	if err != nil {
+		return nil, err
+	}
+	lanHostInfo, err := cmd.Flags().GetBool("lan-host-info")
+	if err != nil {
+		return nil, err
+	}
+	eventLogForwarding, err := cmd.Flags().GetBool("eventlog.log")
+	if err != nil {
+		return nil, err
+	}
+
+	f := &hubExporterFactory{
+		healthThresholds:   health.DefaultThresholds(),
+		lanHostInfo:        lanHostInfo,
+		eventLogForwarding: eventLogForwarding,
+		hubExporters:       map[string]*exporter.HubExporter{},
+	}
+
+	if healthThresholdsFile != "" {
+		f.healthThresholds, err = health.LoadThresholds(healthThresholdsFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if planFile != "" {
+		f.plans, err = plan.LoadPlans(planFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	f.password = os.Getenv(passwordEnv)
+	if passwordFile != "" {
+		f.password, err = readSecretFile(passwordFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	syslogWriter, err := newSyslogWriter(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if syslogWriter != nil {
+		f.eventSinks = append(f.eventSinks, syslogWriter)
+	}
+
+	webhookNotifiers, err := newWebhookNotifiers(cmd)
+	if err != nil {
+		return nil, errors.Join(err, f.eventSinks.Close())
+	}
+	f.eventSinks = append(f.eventSinks, webhookNotifiers...)
+
+	if stateDir != "" {
+		f.store, err = store.Open(stateDir, stateFlushInterval)
+		if err != nil {
+			return nil, errors.Join(err, f.eventSinks.Close())
+		}
+	}
+
+	return f, nil
+}
+
+// New creates a HubExporter for target.
+func (f *hubExporterFactory) New(target string) *exporter.HubExporter {
+	hubExporter := exporter.NewHubExporter(target, 5*time.Second).
+		HealthThresholds(f.healthThresholds).
+		Password(f.password).
+		LanHostInfo(f.lanHostInfo).
+		EventLogForwarding(f.eventLogForwarding)
+	if p, ok := f.plans.Lookup(target); ok {
+		hubExporter.Plan(p)
+	}
+	if len(f.eventSinks) > 0 {
+		hubExporter.EventSink(f.eventSinks)
+	}
+	if f.store != nil {
+		f.store.Register(target, hubExporter)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.hubExporters[target] = hubExporter
+	return hubExporter
+}
+
+// Remove stops persisting the state of the HubExporter created for target, discarding it, and
+// closes it.
+func (f *hubExporterFactory) Remove(target string) error {
+	if f.store != nil {
+		f.store.Unregister(target)
+	}
+	f.mu.Lock()
+	hubExporter, ok := f.hubExporters[target]
+	delete(f.hubExporters, target)
+	f.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	return hubExporter.Close()
+}
+
+// Close closes all created HubExporters, persists their state, and delivers pending events.
+func (f *hubExporterFactory) Close() error {
+	var errs []error
+	f.mu.Lock()
+	for _, hubExporter := range f.hubExporters {
+		errs = append(errs, hubExporter.Close())
+	}
+	f.mu.Unlock()
+	if f.store != nil {
+		errs = append(errs, f.store.Close())
+	}
+	errs = append(errs, f.eventSinks.Close())
+	return errors.Join(errs...)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/fornellas/slogxt/log"
+	"github.com/spf13/cobra"
+
+	"github.com/fornellas/virginmedia_hub6_exporter/influx"
+)
+
+var InfluxCmd = &cobra.Command{
+	Use:   "influx",
+	Short: "Output Virgin Media Hub 6 data as InfluxDB line protocol",
+	Long: "Output Virgin Media Hub 6 data as InfluxDB line protocol.\n\n" +
+		"Without --url, the data is written to stdout once, which is suitable for usage with the " +
+		"Telegraf exec input plugin (data_format = \"influx\"). With --url, it is sent to the " +
+		"InfluxDB v2 write API instead.",
+	Args: cobra.NoArgs,
+	Run: GetRunFn(func(cmd *cobra.Command, args []string) (err error) {
+		logger := log.MustLogger(cmd.Context())
+
+		hubExporterFactory, err := newHubExporterFactory(cmd)
+		if err != nil {
+			return err
+		}
+		defer func() { err = errors.Join(err, hubExporterFactory.Close()) }()
+
+		target, err := cmd.Flags().GetString("target")
+		if err != nil {
+			return err
+		}
+		interval, err := cmd.Flags().GetDuration("interval")
+		if err != nil {
+			return err
+		}
+		url, err := cmd.Flags().GetString("url")
+		if err != nil {
+			return err
+		}
+		org, err := cmd.Flags().GetString("org")
+		if err != nil {
+			return err
+		}
+		bucket, err := cmd.Flags().GetString("bucket")
+		if err != nil {
+			return err
+		}
+		tokenFile, err := cmd.Flags().GetString("token-file")
+		if err != nil {
+			return err
+		}
+
+		token, err := readSecretFile(tokenFile)
+		if err != nil {
+			return err
+		}
+
+		hubExporter := hubExporterFactory.New(target)
+
+		var client *influx.Client
+		if url != "" {
+			client = influx.NewClient(url, org, bucket, token, 10*time.Second)
+		}
+
+		return runInterval(cmd.Context(), interval, func(ctx context.Context) error {
+			s := hubExporter.Scrape(ctx)
+			if err := s.Err(); err != nil {
+				logger.Warn("Failed to scrape Hub", "err", err)
+			}
+			if client == nil {
+				_, err := os.Stdout.Write(influx.Marshal(target, s))
+				return err
+			}
+			if err := client.Write(ctx, target, s); err != nil {
+				return err
+			}
+			logger.Info("Wrote to InfluxDB", "url", url)
+			return nil
+		})
+	}),
+}
+
+func init() {
+	InfluxCmd.Flags().String("target", "", "Address of the Hub to probe")
+	if err := InfluxCmd.MarkFlagRequired("target"); err != nil {
+		panic(err)
+	}
+	InfluxCmd.Flags().Duration("interval", 0, "Output data at this interval; if 0, output once and exit")
+	InfluxCmd.Flags().String("url", "", "URL of the InfluxDB server to write to (eg: http://influxdb:8086); if empty, write to stdout")
+	InfluxCmd.Flags().String("org", "", "InfluxDB organization")
+	InfluxCmd.Flags().String("bucket", "", "InfluxDB bucket")
+	InfluxCmd.Flags().String("token-file", "", "Path of a file containing the InfluxDB API token")
+
+	addHubExporterFlags(InfluxCmd)
+
+	RootCmd.AddCommand(InfluxCmd)
+}
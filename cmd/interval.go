@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/fornellas/slogxt/log"
+)
+
+// runInterval calls fn once if interval is zero, returning its error. Otherwise, it calls fn
+// immediately and then at every interval, logging errors, until the process receives SIGINT or
+// SIGTERM.
+func runInterval(ctx context.Context, interval time.Duration, fn func(context.Context) error) error {
+	if interval == 0 {
+		return fn(ctx)
+	}
+
+	logger := log.MustLogger(ctx)
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := fn(ctx); err != nil {
+			logger.Error(err.Error())
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
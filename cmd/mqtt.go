@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/fornellas/slogxt/log"
+	"github.com/spf13/cobra"
+
+	"github.com/fornellas/virginmedia_hub6_exporter/mqtt"
+)
+
+var MqttCmd = &cobra.Command{
+	Use:   "mqtt",
+	Short: "Periodically publish Virgin Media Hub 6 state to MQTT with Home Assistant discovery",
+	Args:  cobra.NoArgs,
+	Run: GetRunFn(func(cmd *cobra.Command, args []string) (err error) {
+		logger := log.MustLogger(cmd.Context())
+
+		hubExporterFactory, err := newHubExporterFactory(cmd)
+		if err != nil {
+			return err
+		}
+		defer func() { err = errors.Join(err, hubExporterFactory.Close()) }()
+
+		target, err := cmd.Flags().GetString("target")
+		if err != nil {
+			return err
+		}
+		interval, err := cmd.Flags().GetDuration("interval")
+		if err != nil {
+			return err
+		}
+		broker, err := cmd.Flags().GetString("mqtt-broker")
+		if err != nil {
+			return err
+		}
+		clientId, err := cmd.Flags().GetString("mqtt-client-id")
+		if err != nil {
+			return err
+		}
+		username, err := cmd.Flags().GetString("mqtt-username")
+		if err != nil {
+			return err
+		}
+		passwordFile, err := cmd.Flags().GetString("mqtt-password-file")
+		if err != nil {
+			return err
+		}
+		topicPrefix, err := cmd.Flags().GetString("topic-prefix")
+		if err != nil {
+			return err
+		}
+		discoveryPrefix, err := cmd.Flags().GetString("discovery-prefix")
+		if err != nil {
+			return err
+		}
+
+		password, err := readSecretFile(passwordFile)
+		if err != nil {
+			return err
+		}
+
+		availabilityTopic := mqtt.AvailabilityTopic(topicPrefix, target)
+		opts := mqtt.NewClientOptions(broker, topicPrefix, target).
+			SetClientID(clientId).
+			SetUsername(username).
+			SetPassword(password)
+		client := paho.NewClient(opts)
+		token := client.Connect()
+		if !token.WaitTimeout(30 * time.Second) {
+			return fmt.Errorf("timeout connecting to %s", broker)
+		}
+		if err := token.Error(); err != nil {
+			return err
+		}
+		defer func() {
+			// When publishing once, the state must remain available after exiting
+			if interval != 0 {
+				client.Publish(availabilityTopic, 1, true, "offline").WaitTimeout(5 * time.Second)
+			}
+			client.Disconnect(1000)
+		}()
+		logger.Info("Connected", "broker", broker)
+
+		hubExporter := hubExporterFactory.New(target)
+		publisher := mqtt.NewPublisher(client, target, topicPrefix, discoveryPrefix)
+
+		return runInterval(cmd.Context(), interval, func(ctx context.Context) error {
+			s := hubExporter.Scrape(ctx)
+			if err := s.Err(); err != nil {
+				logger.Warn("Failed to scrape Hub", "err", err)
+			}
+			if err := publisher.Publish(s); err != nil {
+				return err
+			}
+			logger.Info("Published", "broker", broker)
+			return nil
+		})
+	}),
+}
+
+func init() {
+	MqttCmd.Flags().String("target", "", "Address of the Hub to probe")
+	if err := MqttCmd.MarkFlagRequired("target"); err != nil {
+		panic(err)
+	}
+	MqttCmd.Flags().Duration("interval", time.Minute, "Publish at this interval; if 0, publish once and exit")
+	MqttCmd.Flags().String("mqtt-broker", "tcp://localhost:1883", "MQTT broker URL (tcp://, ssl:// or ws://)")
+	MqttCmd.Flags().String("mqtt-client-id", "virginmedia_hub6_exporter", "MQTT client ID")
+	MqttCmd.Flags().String("mqtt-username", "", "MQTT username")
+	MqttCmd.Flags().String("mqtt-password-file", "", "Path of a file containing the MQTT password")
+	MqttCmd.Flags().String("topic-prefix", "virginmedia_hub6", "Prefix of the topics where state is published")
+	MqttCmd.Flags().String("discovery-prefix", "homeassistant", "Home Assistant MQTT discovery prefix")
+
+	addHubExporterFlags(MqttCmd)
+
+	RootCmd.AddCommand(MqttCmd)
+}
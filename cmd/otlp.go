@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fornellas/slogxt/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/spf13/cobra"
+	"go.opentelemetry.io/otel"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/resource"
+
+	"github.com/fornellas/virginmedia_hub6_exporter/exporter"
+	hub6 "github.com/fornellas/virginmedia_hub6_exporter/hub6"
+	"github.com/fornellas/virginmedia_hub6_exporter/otlp"
+)
+
+var OtlpCmd = &cobra.Command{
+	Use:   "otlp",
+	Short: "Periodically export Virgin Media Hub 6 metrics via OTLP",
+	Args:  cobra.NoArgs,
+	Run: GetRunFn(func(cmd *cobra.Command, args []string) (err error) {
+		logger := log.MustLogger(cmd.Context())
+
+		hubExporterFactory, err := newHubExporterFactory(cmd)
+		if err != nil {
+			return err
+		}
+		defer func() { err = errors.Join(err, hubExporterFactory.Close()) }()
+
+		target, err := cmd.Flags().GetString("target")
+		if err != nil {
+			return err
+		}
+		interval, err := cmd.Flags().GetDuration("interval")
+		if err != nil {
+			return err
+		}
+		protocol, err := cmd.Flags().GetString("otlp-protocol")
+		if err != nil {
+			return err
+		}
+		endpoint, err := cmd.Flags().GetString("otlp-endpoint")
+		if err != nil {
+			return err
+		}
+		insecure, err := cmd.Flags().GetBool("otlp-insecure")
+		if err != nil {
+			return err
+		}
+
+		if interval <= 0 {
+			return errors.New("--interval must be positive")
+		}
+
+		otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+			logger.Error("OpenTelemetry error", "err", err)
+		}))
+
+		metricExporter, err := otlp.NewExporter(cmd.Context(), protocol, endpoint, insecure)
+		if err != nil {
+			return err
+		}
+
+		collector := &identifyingCollector{
+			hubExporter: hubExporterFactory.New(target),
+			logger:      logger,
+		}
+		registry := prometheus.NewRegistry()
+		registry.MustRegister(collector)
+
+		reader := sdkmetric.NewPeriodicReader(
+			&resourceExporter{
+				Exporter: metricExporter,
+				// Until the modem is first reachable, its resource attributes are unknown
+				resource: func() (*resource.Resource, error) {
+					return otlp.NewResource(target, collector.CableModem())
+				},
+			},
+			sdkmetric.WithInterval(interval),
+			sdkmetric.WithProducer(otlp.NewProducer(registry)),
+		)
+		meterProvider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+		defer func() {
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			err = errors.Join(err, meterProvider.Shutdown(shutdownCtx))
+		}()
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		logger.Info("Exporting metrics", "protocol", protocol, "interval", interval)
+		// The periodic reader first exports after interval
+		if err := meterProvider.ForceFlush(ctx); err != nil {
+			logger.Error("Failed to export metrics", "err", err)
+		}
+		<-ctx.Done()
+		return nil
+	}),
+}
+
+// resourceExporter is an sdkmetric.Exporter which sets the resource of metrics as they are
+// exported, as the modem identification is only known once it was reachable.
+type resourceExporter struct {
+	sdkmetric.Exporter
+	resource func() (*resource.Resource, error)
+}
+
+func (e *resourceExporter) Export(ctx context.Context, rm *metricdata.ResourceMetrics) error {
+	res, err := e.resource()
+	if err != nil {
+		return err
+	}
+	rm.Resource = res
+	return e.Exporter.Export(ctx, rm)
+}
+
+// identifyingCollector collects metrics from a Hub, and keeps the modem identification from the
+// latest scrape which had it.
+type identifyingCollector struct {
+	hubExporter *exporter.HubExporter
+	logger      *slog.Logger
+
+	mu         sync.Mutex
+	cableModem *hub6.CableModem
+}
+
+func (c *identifyingCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.hubExporter.Describe(ch)
+}
+
+func (c *identifyingCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.hubExporter.Scrape(context.Background())
+	if err := s.Err(); err != nil {
+		c.logger.Warn("Failed to scrape Hub", "err", err)
+	}
+	c.hubExporter.ScrapeCollector(s).Collect(ch)
+	if s.State != nil {
+		c.mu.Lock()
+		c.cableModem = &s.State.CableModem
+		c.mu.Unlock()
+	}
+}
+
+// CableModem returns the modem identification, or nil if the modem was not reachable yet.
+func (c *identifyingCollector) CableModem() *hub6.CableModem {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cableModem
+}
+
+func init() {
+	OtlpCmd.Flags().String("target", "", "Address of the Hub to probe")
+	if err := OtlpCmd.MarkFlagRequired("target"); err != nil {
+		panic(err)
+	}
+	OtlpCmd.Flags().Duration("interval", time.Minute, "Interval at which metrics are collected and exported")
+	OtlpCmd.Flags().String("otlp-protocol", "grpc", "OTLP protocol: grpc or http")
+	OtlpCmd.Flags().String("otlp-endpoint", "", "OTLP endpoint host:port (eg: otel-collector:4317); if empty, the standard OTEL_EXPORTER_OTLP_* environment variables are used")
+	OtlpCmd.Flags().Bool("otlp-insecure", false, "Disable TLS for the OTLP connection")
+
+	addHubExporterFlags(OtlpCmd)
+
+	RootCmd.AddCommand(OtlpCmd)
+}
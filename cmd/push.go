@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fornellas/slogxt/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/spf13/cobra"
+
+	"github.com/fornellas/virginmedia_hub6_exporter/remotewrite"
+)
+
+var PushCmd = &cobra.Command{
+	Use:   "push",
+	Short: "Periodically push Virgin Media Hub 6 metrics to a Pushgateway or remote_write endpoint",
+	Args:  cobra.NoArgs,
+	Run: GetRunFn(func(cmd *cobra.Command, args []string) (err error) {
+		logger := log.MustLogger(cmd.Context())
+
+		hubExporterFactory, err := newHubExporterFactory(cmd)
+		if err != nil {
+			return err
+		}
+		defer func() { err = errors.Join(err, hubExporterFactory.Close()) }()
+
+		target, err := cmd.Flags().GetString("target")
+		if err != nil {
+			return err
+		}
+		interval, err := cmd.Flags().GetDuration("interval")
+		if err != nil {
+			return err
+		}
+		job, err := cmd.Flags().GetString("job")
+		if err != nil {
+			return err
+		}
+		pushgatewayURL, err := cmd.Flags().GetString("pushgateway-url")
+		if err != nil {
+			return err
+		}
+		remoteWriteURL, err := cmd.Flags().GetString("remote-write-url")
+		if err != nil {
+			return err
+		}
+		username, err := cmd.Flags().GetString("basic-auth-username")
+		if err != nil {
+			return err
+		}
+		passwordFile, err := cmd.Flags().GetString("basic-auth-password-file")
+		if err != nil {
+			return err
+		}
+
+		if pushgatewayURL == "" && remoteWriteURL == "" {
+			return errors.New("at least one of --pushgateway-url or --remote-write-url must be set")
+		}
+
+		password, err := readSecretFile(passwordFile)
+		if err != nil {
+			return err
+		}
+
+		registry := prometheus.NewRegistry()
+		registry.MustRegister(hubExporterFactory.New(target))
+
+		// Metrics are gathered once per interval and sent to each endpoint, as every scrape of the
+		// Hub updates state derived across scrapes (eg: error rates)
+		var families []*dto.MetricFamily
+		gatherer := prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+			return families, nil
+		})
+
+		var pusher *push.Pusher
+		if pushgatewayURL != "" {
+			pusher = push.New(pushgatewayURL, job).
+				Gatherer(gatherer).
+				Grouping("instance", target)
+			if username != "" {
+				pusher = pusher.BasicAuth(username, password)
+			}
+		}
+
+		var remoteWriteClient *remotewrite.Client
+		if remoteWriteURL != "" {
+			remoteWriteClient = remotewrite.NewClient(remoteWriteURL, 10*time.Second).
+				Label("job", job).
+				Label("instance", target)
+			if username != "" {
+				remoteWriteClient = remoteWriteClient.BasicAuth(username, password)
+			}
+		}
+
+		return runInterval(cmd.Context(), interval, func(ctx context.Context) error {
+			var err error
+			families, err = registry.Gather()
+			if err != nil {
+				return err
+			}
+			var errs []error
+			if pusher != nil {
+				if err := pusher.PushContext(ctx); err != nil {
+					errs = append(errs, fmt.Errorf("pushgateway: %w", err))
+				} else {
+					logger.Info("Pushed metrics", "pushgateway", pushgatewayURL)
+				}
+			}
+			if remoteWriteClient != nil {
+				if err := remoteWriteClient.Write(ctx, gatherer); err != nil {
+					errs = append(errs, fmt.Errorf("remote_write: %w", err))
+				} else {
+					logger.Info("Pushed metrics", "remote_write", remoteWriteURL)
+				}
+			}
+			return errors.Join(errs...)
+		})
+	}),
+}
+
+func init() {
+	PushCmd.Flags().String("target", "", "Address of the Hub to probe")
+	if err := PushCmd.MarkFlagRequired("target"); err != nil {
+		panic(err)
+	}
+	PushCmd.Flags().Duration("interval", time.Minute, "Push metrics at this interval; if 0, push once and exit")
+	PushCmd.Flags().String("job", "virginmedia_hub6", "Value of the job label of pushed metrics")
+	PushCmd.Flags().String("pushgateway-url", "", "URL of the Pushgateway to push to (eg: http://pushgateway:9091)")
+	PushCmd.Flags().String("remote-write-url", "", "URL of the Prometheus remote_write endpoint to send to (eg: http://prometheus:9090/api/v1/write)")
+	PushCmd.Flags().String("basic-auth-username", "", "HTTP basic authentication username")
+	PushCmd.Flags().String("basic-auth-password-file", "", "Path of a file containing the HTTP basic authentication password")
+
+	addHubExporterFlags(PushCmd)
+
+	RootCmd.AddCommand(PushCmd)
+}
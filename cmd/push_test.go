@@ -0,0 +1,63 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestPushGathersOncePerInterval(t *testing.T) {
+	var mu sync.Mutex
+	requests := map[string]int{}
+	count := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			requests[name]++
+			mu.Unlock()
+			if name == "hub" {
+				http.NotFound(w, r)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}
+	}
+	hub := httptest.NewServer(count("hub"))
+	defer hub.Close()
+	pushgateway := httptest.NewServer(count("pushgateway"))
+	defer pushgateway.Close()
+	remoteWrite := httptest.NewServer(count("remote_write"))
+	defer remoteWrite.Close()
+
+	RootCmd.SetArgs([]string{
+		"push",
+		"--target", strings.TrimPrefix(hub.URL, "http://"),
+		"--interval", "0",
+		"--pushgateway-url", pushgateway.URL,
+		"--remote-write-url", remoteWrite.URL,
+	})
+	defer RootCmd.SetArgs(nil)
+	if err := RootCmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	if requests["pushgateway"] != 1 || requests["remote_write"] != 1 {
+		t.Errorf("expected one push to each endpoint, got %v", requests)
+	}
+	hubRequests := requests["hub"]
+	// Scrape the Hub again, to compare with the requests of a single scrape
+	RootCmd.SetArgs([]string{
+		"push",
+		"--target", strings.TrimPrefix(hub.URL, "http://"),
+		"--interval", "0",
+		"--pushgateway-url", pushgateway.URL,
+		"--remote-write-url", "",
+	})
+	if err := RootCmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if single := requests["hub"] - hubRequests; single != hubRequests {
+		t.Errorf("pushing to both endpoints made %d Hub requests, expected those of a single scrape (%d)", hubRequests, single)
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/fornellas/virginmedia_hub6_exporter/report"
+)
+
+var ReportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Report the availability of Virgin Media Hubs",
+	Long: "Report the availability of Virgin Media Hubs: outages (Hub unreachable, not operational " +
+		"or rebooting), their durations, MTBF / MTTR and monthly uptime.\n\n" +
+		"History is read either from the history endpoint of the server command (--history-url) " +
+		"or from a Prometheus compatible query endpoint (--prometheus-url) storing the exporter " +
+		"metrics. Time not covered by history is not counted towards availability.",
+	Args: cobra.NoArgs,
+	Run: GetRunFn(func(cmd *cobra.Command, args []string) (err error) {
+		historyURL, err := cmd.Flags().GetString("history-url")
+		if err != nil {
+			return err
+		}
+		prometheusURL, err := cmd.Flags().GetString("prometheus-url")
+		if err != nil {
+			return err
+		}
+		targetLabel, err := cmd.Flags().GetString("prometheus.target-label")
+		if err != nil {
+			return err
+		}
+		since, err := cmd.Flags().GetDuration("since")
+		if err != nil {
+			return err
+		}
+		step, err := cmd.Flags().GetDuration("step")
+		if err != nil {
+			return err
+		}
+		maxGap, err := cmd.Flags().GetDuration("max-gap")
+		if err != nil {
+			return err
+		}
+		format, err := cmd.Flags().GetString("format")
+		if err != nil {
+			return err
+		}
+		out, err := cmd.Flags().GetString("out")
+		if err != nil {
+			return err
+		}
+
+		if (historyURL == "") == (prometheusURL == "") {
+			return errors.New("exactly one of --history-url or --prometheus-url is required")
+		}
+		switch format {
+		case report.Markdown, report.HTML, report.JSON:
+		default:
+			return errors.New("--format must be one of markdown, html or json")
+		}
+
+		client := &http.Client{Timeout: time.Minute}
+		end := time.Now()
+		start := end.Add(-since)
+
+		var samplesByTarget map[string][]report.Sample
+		if historyURL != "" {
+			samplesByTarget, err = report.FromHistory(cmd.Context(), client, historyURL)
+		} else {
+			samplesByTarget, err = report.FromPrometheus(
+				cmd.Context(), client, prometheusURL, targetLabel, start, end, step,
+			)
+		}
+		if err != nil {
+			return err
+		}
+
+		targets := make([]string, 0, len(samplesByTarget))
+		for target := range samplesByTarget {
+			targets = append(targets, target)
+		}
+		sort.Strings(targets)
+		reports := make([]report.Report, 0, len(targets))
+		for _, target := range targets {
+			var samples []report.Sample
+			for _, s := range samplesByTarget[target] {
+				if !s.Time.Before(start) {
+					samples = append(samples, s)
+				}
+			}
+			reports = append(reports, report.Analyze(target, samples, maxGap))
+		}
+
+		var w io.Writer = os.Stdout
+		if out != "" {
+			f, err := os.Create(out)
+			if err != nil {
+				return err
+			}
+			defer func() { err = errors.Join(err, f.Close()) }()
+			w = f
+		}
+		return report.Write(w, format, reports)
+	}),
+}
+
+func init() {
+	ReportCmd.Flags().String("history-url", "", "URL of the history endpoint of the server command (eg: http://localhost:9188/history/)")
+	ReportCmd.Flags().String("prometheus-url", "", "URL of a Prometheus compatible server to query (eg: http://prometheus:9090)")
+	ReportCmd.Flags().String("prometheus.target-label", "instance", "Label identifying the Hub in Prometheus series")
+	ReportCmd.Flags().Duration("since", 30*24*time.Hour, "Report on history since this long ago")
+	ReportCmd.Flags().Duration("step", time.Minute, "Resolution of Prometheus queries")
+	ReportCmd.Flags().Duration("max-gap", 5*time.Minute, "Maximum time between samples; longer gaps are not observed")
+	ReportCmd.Flags().String("format", report.Markdown, "Report format: markdown, html or json")
+	ReportCmd.Flags().String("out", "", "Path of the file to write the report to; if empty, write to stdout")
+
+	RootCmd.AddCommand(ReportCmd)
+}
@@ -34,11 +34,22 @@ var RootCmd = &cobra.Command{
 		// Inspired by https://github.com/spf13/viper/issues/671#issuecomment-671067523
 		v := viper.New()
 		v.SetEnvPrefix("VM_HUB6_EXPORTER")
-		v.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
+		// eg: --state.dir and --plan-file are set by VM_HUB6_EXPORTER_STATE_DIR and
+		// VM_HUB6_EXPORTER_PLAN_FILE
+		v.SetEnvKeyReplacer(strings.NewReplacer("-", "_", ".", "_"))
 		v.AutomaticEnv()
 		cmd.Flags().VisitAll(func(f *pflag.Flag) {
 			if !f.Changed && v.IsSet(f.Name) {
-				cmd.Flags().Set(f.Name, fmt.Sprintf("%v", v.Get(f.Name)))
+				value := fmt.Sprintf("%v", v.Get(f.Name))
+				// Values of string arrays (eg: URLs, which may contain commas) are whitespace
+				// separated
+				if f.Value.Type() == "stringArray" {
+					for _, s := range strings.Fields(value) {
+						cmd.Flags().Set(f.Name, s)
+					}
+					return
+				}
+				cmd.Flags().Set(f.Name, value)
 			}
 		})
 
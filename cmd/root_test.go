@@ -0,0 +1,77 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func TestEnvironmentFlags(t *testing.T) {
+	t.Setenv("VM_HUB6_EXPORTER_STATE_DIR", "/var/lib/exporter")
+	t.Setenv("VM_HUB6_EXPORTER_POLL_INTERVAL", "30s")
+	t.Setenv("VM_HUB6_EXPORTER_PLAN_FILE", "/etc/plans.json")
+	t.Setenv("VM_HUB6_EXPORTER_TARGET", "from-env")
+
+	var (
+		stateDir     string
+		pollInterval time.Duration
+		planFile     string
+		target       string
+	)
+	cmd := &cobra.Command{
+		Use: "env-test",
+		Run: func(cmd *cobra.Command, args []string) {},
+	}
+	cmd.Flags().StringVar(&stateDir, "state.dir", "", "")
+	cmd.Flags().DurationVar(&pollInterval, "poll.interval", time.Minute, "")
+	cmd.Flags().StringVar(&planFile, "plan-file", "", "")
+	cmd.Flags().StringVar(&target, "target", "", "")
+	RootCmd.AddCommand(cmd)
+	defer RootCmd.RemoveCommand(cmd)
+
+	// Flags given on the command line take precedence
+	RootCmd.SetArgs([]string{"env-test", "--target", "from-flag"})
+	defer RootCmd.SetArgs(nil)
+	if err := RootCmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	if stateDir != "/var/lib/exporter" {
+		t.Errorf("--state.dir: got %q", stateDir)
+	}
+	if pollInterval != 30*time.Second {
+		t.Errorf("--poll.interval: got %v", pollInterval)
+	}
+	if planFile != "/etc/plans.json" {
+		t.Errorf("--plan-file: got %q", planFile)
+	}
+	if target != "from-flag" {
+		t.Errorf("--target: got %q", target)
+	}
+}
+
+func TestEnvironmentStringArrayFlags(t *testing.T) {
+	t.Setenv("VM_HUB6_EXPORTER_WEBHOOK", "ntfy=https://ntfy.example.com/a,b\n  slack=https://hooks.example.com/c")
+
+	var webhooks []string
+	cmd := &cobra.Command{
+		Use: "env-array-test",
+		Run: func(cmd *cobra.Command, args []string) {},
+	}
+	cmd.Flags().StringArrayVar(&webhooks, "webhook", nil, "")
+	RootCmd.AddCommand(cmd)
+	defer RootCmd.RemoveCommand(cmd)
+
+	RootCmd.SetArgs([]string{"env-array-test"})
+	defer RootCmd.SetArgs(nil)
+	if err := RootCmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"ntfy=https://ntfy.example.com/a,b", "slack=https://hooks.example.com/c"}
+	if !slices.Equal(webhooks, expected) {
+		t.Errorf("--webhook: got %q, expected %q", webhooks, expected)
+	}
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"os"
+	"strings"
+)
+
+// readSecretFile returns the contents of path, without leading or trailing white space. This
+// is to be used for secrets, so they don't show up in the process arguments.
+func readSecretFile(path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
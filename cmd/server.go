@@ -1,8 +1,15 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/fornellas/slogxt/log"
@@ -11,21 +18,207 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/fornellas/virginmedia_hub6_exporter/exporter"
+	"github.com/fornellas/virginmedia_hub6_exporter/history"
+	"github.com/fornellas/virginmedia_hub6_exporter/netprobe"
 )
 
+// pollHubs starts scraping each of targets in the background at every interval, until ctx is
+// done, calling fn with each scrape. wg is done when all of them exit.
+func pollHubs(
+	ctx context.Context,
+	wg *sync.WaitGroup,
+	targets []string,
+	interval time.Duration,
+	getHubExporter func(target string) *exporter.HubExporter,
+	fn func(target string, s *exporter.Scrape),
+) {
+	logger := log.MustLogger(ctx)
+	for _, target := range targets {
+		hubExporter := getHubExporter(target)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				s := hubExporter.Scrape(ctx)
+				if err := s.Err(); err != nil {
+					logger.Warn("Failed to poll Hub", "target", target, "err", err)
+				}
+				fn(target, s)
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}()
+	}
+}
+
+// maxProbedTargets is the maximum number of targets, other than each --hub, for which state is
+// kept across scrapes of /probe.
+const maxProbedTargets = 100
+
 var ServerCmd = &cobra.Command{
 	Use:   "server",
 	Short: "Run the Virgin Media Hub 6 Prometheus exporter HTTP server",
-	Run: GetRunFn(func(cmd *cobra.Command, args []string) error {
+	Run: GetRunFn(func(cmd *cobra.Command, args []string) (err error) {
 		logger := log.MustLogger(cmd.Context())
 
+		hubExporterFactory, err := newHubExporterFactory(cmd)
+		if err != nil {
+			return err
+		}
+		defer func() { err = errors.Join(err, hubExporterFactory.Close()) }()
+
 		port, err := cmd.Flags().GetInt("port")
 		if err != nil {
 			return err
 		}
+		hubs, err := cmd.Flags().GetStringSlice("hub")
+		if err != nil {
+			return err
+		}
+		poll, err := cmd.Flags().GetBool("poll")
+		if err != nil {
+			return err
+		}
+		pollInterval, err := cmd.Flags().GetDuration("poll.interval")
+		if err != nil {
+			return err
+		}
+		historyRetention, err := cmd.Flags().GetDuration("history.retention")
+		if err != nil {
+			return err
+		}
+		probeConnectAddresses, err := cmd.Flags().GetStringSlice("probe.connect-address")
+		if err != nil {
+			return err
+		}
+		probeDownloadURLs, err := cmd.Flags().GetStringSlice("probe.download-url")
+		if err != nil {
+			return err
+		}
+		probeInterval, err := cmd.Flags().GetDuration("probe.interval")
+		if err != nil {
+			return err
+		}
+		probeTimeout, err := cmd.Flags().GetDuration("probe.timeout")
+		if err != nil {
+			return err
+		}
+		probeMaxBytes, err := cmd.Flags().GetInt64("probe.max-bytes")
+		if err != nil {
+			return err
+		}
+
+		if len(hubs) > 0 && pollInterval <= 0 {
+			return errors.New("--poll.interval must be positive")
+		}
+
+		// Shut down gracefully, so state can be persisted on exit. Background polls must exit
+		// before the exporters, their event sinks and the state store are closed.
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		var pollers sync.WaitGroup
+		defer func() {
+			stop()
+			pollers.Wait()
+		}()
+
+		// Exporters are kept per target, as they hold state across scrapes (eg: reboot detection).
+		// Targets are given by clients, so other than each --hub, only the most recently probed
+		// ones are kept.
+		type probedHubExporter struct {
+			hubExporter *exporter.HubExporter
+			lastProbed  time.Time
+		}
+		var hubExportersMu sync.Mutex
+		hubExporters := map[string]*exporter.HubExporter{}
+		for _, hub := range hubs {
+			hubExporters[hub] = hubExporterFactory.New(hub)
+		}
+		probedHubExporters := map[string]*probedHubExporter{}
+		getHubExporter := func(target string) *exporter.HubExporter {
+			hubExportersMu.Lock()
+			defer hubExportersMu.Unlock()
+			if hubExporter, ok := hubExporters[target]; ok {
+				return hubExporter
+			}
+			if p, ok := probedHubExporters[target]; ok {
+				p.lastProbed = time.Now()
+				return p.hubExporter
+			}
+			if len(probedHubExporters) >= maxProbedTargets {
+				var oldestTarget string
+				var oldest *probedHubExporter
+				for t, p := range probedHubExporters {
+					if oldest == nil || p.lastProbed.Before(oldest.lastProbed) {
+						oldestTarget, oldest = t, p
+					}
+				}
+				delete(probedHubExporters, oldestTarget)
+				if err := hubExporterFactory.Remove(oldestTarget); err != nil {
+					logger.Warn("Failed to close exporter", "target", oldestTarget, "err", err)
+				}
+			}
+			hubExporter := hubExporterFactory.New(target)
+			probedHubExporters[target] = &probedHubExporter{hubExporter: hubExporter, lastProbed: time.Now()}
+			return hubExporter
+		}
+
+		// Network probes run in the background, and are exported along with each --hub
+		var prober *netprobe.Prober
+		if len(probeConnectAddresses) > 0 || len(probeDownloadURLs) > 0 {
+			if len(hubs) == 0 {
+				return errors.New("--probe.connect-address and --probe.download-url require at least one --hub")
+			}
+			if probeInterval <= 0 {
+				return errors.New("--probe.interval must be positive")
+			}
+			prober = netprobe.NewProber(probeConnectAddresses, probeDownloadURLs, probeTimeout, probeMaxBytes)
+			prober.Start(ctx, probeInterval)
+		}
+
+		// Hubs are optionally polled in the background, independently of scrapes
+		var histories map[string]*history.History
+		if historyRetention > 0 {
+			histories = map[string]*history.History{}
+			for _, hub := range hubs {
+				histories[hub] = history.NewHistory(int(historyRetention / pollInterval))
+			}
+		}
+		var polledSnapshots *snapshots
+		if poll {
+			polledSnapshots = newSnapshots()
+		}
+		if histories != nil || polledSnapshots != nil {
+			if len(hubs) == 0 {
+				return errors.New("--poll and --history.retention require at least one --hub")
+			}
+			pollHubs(ctx, &pollers, hubs, pollInterval, getHubExporter, func(target string, s *exporter.Scrape) {
+				if histories != nil {
+					histories[target].Add(s)
+				}
+				if polledSnapshots != nil {
+					registry := prometheus.NewRegistry()
+					registry.MustRegister(getHubExporter(target).ScrapeCollector(s))
+					if prober != nil {
+						registry.MustRegister(prober)
+					}
+					families, err := registry.Gather()
+					if err != nil {
+						logger.Error("Failed to gather metrics", "target", target, "err", err)
+						return
+					}
+					polledSnapshots.set(target, families)
+				}
+			})
+		}
 
 		// /probe implements the multi-target exporter pattern. It expects a GET
-		// parameter "target" containing the address of the Hub to probe.
+		// parameter "target" containing the address of the Hub to probe. Polled hubs are
+		// served from their latest snapshot.
 		mux := http.NewServeMux()
 		mux.HandleFunc("/probe", func(w http.ResponseWriter, r *http.Request) {
 			target := r.URL.Query().Get("target")
@@ -34,22 +227,78 @@ var ServerCmd = &cobra.Command{
 				return
 			}
 
-			registry := prometheus.NewRegistry()
-			hubExporter := exporter.NewHubExporter(target, 5*time.Second)
-			registry.MustRegister(hubExporter)
+			var gatherer prometheus.Gatherer
+			if polledSnapshots != nil && slices.Contains(hubs, target) {
+				var ok bool
+				gatherer, ok = polledSnapshots.gatherer(target)
+				if !ok {
+					http.Error(w, "target not polled yet", http.StatusServiceUnavailable)
+					return
+				}
+			} else {
+				registry := prometheus.NewRegistry()
+				registry.MustRegister(getHubExporter(target))
+				if prober != nil && slices.Contains(hubs, target) {
+					registry.MustRegister(prober)
+				}
+				gatherer = registry
+			}
 
-			handler := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+			handler := promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{})
 			handler.ServeHTTP(w, r)
 		})
 
+		// /metrics serves the exporter's own metrics, and the latest snapshot of all polled hubs,
+		// with a target label.
+		var metricsGatherer prometheus.Gatherer = prometheus.DefaultGatherer
+		if polledSnapshots != nil {
+			metricsGatherer = prometheus.Gatherers{prometheus.DefaultGatherer, polledSnapshots}
+		}
+		mux.Handle("/metrics", promhttp.HandlerFor(metricsGatherer, promhttp.HandlerOpts{}))
+
+		// /history serves a dashboard with recent samples of each polled hub.
+		if histories != nil {
+			mux.Handle("/history/", http.StripPrefix("/history", history.Handler(histories)))
+		}
+
 		listen := fmt.Sprintf(":%d", port)
+		server := &http.Server{Addr: listen, Handler: mux}
+
+		shutdownDone := make(chan struct{})
+		go func() {
+			defer close(shutdownDone)
+			<-ctx.Done()
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if err := server.Shutdown(shutdownCtx); err != nil {
+				logger.Error("Failed to shut down server", "err", err)
+			}
+		}()
+
 		logger.Info("Starting server", "listen", listen)
-		return http.ListenAndServe(listen, mux)
+		if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		// ListenAndServe returns as soon as Shutdown is called; wait for in-flight scrapes
+		<-shutdownDone
+		return nil
 	}),
 }
 
 func init() {
 	ServerCmd.Flags().Int("port", 9188, "HTTP listen port for the exporter")
+	ServerCmd.Flags().StringSlice("hub", nil, "Address of a Hub to poll in the background; may be repeated")
+	ServerCmd.Flags().Bool("poll", false, "Serve /probe for each --hub, and /metrics for all of them, from their latest background poll, instead of querying them on every scrape")
+	ServerCmd.Flags().Duration("poll.interval", time.Minute, "Interval at which each --hub is polled in the background")
+	ServerCmd.Flags().Duration("history.retention", 0, "Keep in memory history of each --hub for this long, served as a dashboard at /history/ (eg: 24h); if 0, history is disabled")
+
+	ServerCmd.Flags().StringSlice("probe.connect-address", nil, "Measure TCP connect latency to this host:port, exported along with each --hub; may be repeated")
+	ServerCmd.Flags().StringSlice("probe.download-url", nil, "Measure HTTP download throughput from this URL, exported along with each --hub; may be repeated")
+	ServerCmd.Flags().Duration("probe.interval", 5*time.Minute, "Interval at which network probes run")
+	ServerCmd.Flags().Duration("probe.timeout", 30*time.Second, "Timeout of each network probe; downloads reaching it are measured up to that point")
+	ServerCmd.Flags().Int64("probe.max-bytes", 100*1024*1024, "Maximum number of bytes read by each download probe")
+
+	addHubExporterFlags(ServerCmd)
 
 	RootCmd.AddCommand(ServerCmd)
 }
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// snapshots holds the latest metrics gathered from each Hub polled in the background.
+type snapshots struct {
+	mu sync.Mutex
+	// Metric families by target
+	families map[string][]*dto.MetricFamily
+}
+
+func newSnapshots() *snapshots {
+	return &snapshots{
+		families: map[string][]*dto.MetricFamily{},
+	}
+}
+
+func (s *snapshots) set(target string, families []*dto.MetricFamily) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.families[target] = families
+}
+
+// gatherer returns a Gatherer for the latest metrics of target, or false if target was not
+// polled yet.
+func (s *snapshots) gatherer(target string) (prometheus.Gatherer, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	families, ok := s.families[target]
+	if !ok {
+		return nil, false
+	}
+	return prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		return families, nil
+	}), true
+}
+
+// Gather returns the latest metrics of all targets, with an added target label.
+func (s *snapshots) Gather() ([]*dto.MetricFamily, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	byName := map[string]*dto.MetricFamily{}
+	for target, families := range s.families {
+		labelName := "target"
+		for _, mf := range families {
+			merged, ok := byName[mf.GetName()]
+			if !ok {
+				merged = &dto.MetricFamily{Name: mf.Name, Help: mf.Help, Type: mf.Type}
+				byName[mf.GetName()] = merged
+			}
+			for _, m := range mf.Metric {
+				metric := *m
+				metric.Label = append([]*dto.LabelPair{{Name: &labelName, Value: &target}}, m.Label...)
+				sort.Slice(metric.Label, func(i, j int) bool {
+					return metric.Label[i].GetName() < metric.Label[j].GetName()
+				})
+				merged.Metric = append(merged.Metric, &metric)
+			}
+		}
+	}
+
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	families := make([]*dto.MetricFamily, 0, len(names))
+	for _, name := range names {
+		families = append(families, byName[name])
+	}
+	return families, nil
+}
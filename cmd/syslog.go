@@ -0,0 +1,68 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/fornellas/virginmedia_hub6_exporter/syslog"
+)
+
+// addSyslogFlags adds the flags used by newSyslogWriter to cmd.
+func addSyslogFlags(cmd *cobra.Command) {
+	cmd.Flags().String("syslog.address", "", "Forward detected events (eg: reboots, lock loss, event log entries) as RFC 5424 syslog messages to this address (eg: syslog.example.com:514 or /dev/log); if empty, events are not forwarded to syslog")
+	cmd.Flags().String("syslog.network", "udp", "Network used to reach --syslog.address: udp, tcp, tls, unix or unixgram")
+	cmd.Flags().String("syslog.facility", "daemon", "Syslog facility of messages (eg: daemon or local0)")
+	cmd.Flags().String("syslog.tls.ca-file", "", "Path of a PEM file with the CA certificates used to verify the syslog server, instead of the system ones")
+}
+
+// newSyslogWriter reads the flags added by addSyslogFlags, and creates a syslog.Writer from
+// them, or returns nil if no address is set.
+func newSyslogWriter(cmd *cobra.Command) (*syslog.Writer, error) {
+	address, err := cmd.Flags().GetString("syslog.address")
+	if err != nil {
+		return nil, err
+	}
+	network, err := cmd.Flags().GetString("syslog.network")
+	if err != nil {
+		return nil, err
+	}
+	facilityName, err := cmd.Flags().GetString("syslog.facility")
+	if err != nil {
+		return nil, err
+	}
+	caFile, err := cmd.Flags().GetString("syslog.tls.ca-file")
+	if err != nil {
+		return nil, err
+	}
+
+	if address == "" {
+		return nil, nil
+	}
+
+	facility, err := syslog.ParseFacility(facilityName)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{}
+	if caFile != "" {
+		data, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no certificates found in %s", caFile)
+		}
+	}
+
+	writer, err := syslog.NewWriter(network, address)
+	if err != nil {
+		return nil, err
+	}
+	return writer.Facility(facility).TLSConfig(tlsConfig), nil
+}
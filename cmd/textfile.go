@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/fornellas/slogxt/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/spf13/cobra"
+)
+
+var TextfileCmd = &cobra.Command{
+	Use:   "textfile",
+	Short: "Write Virgin Media Hub 6 metrics to a file for the node_exporter textfile collector",
+	Args:  cobra.NoArgs,
+	Run: GetRunFn(func(cmd *cobra.Command, args []string) (err error) {
+		logger := log.MustLogger(cmd.Context())
+
+		hubExporterFactory, err := newHubExporterFactory(cmd)
+		if err != nil {
+			return err
+		}
+		defer func() { err = errors.Join(err, hubExporterFactory.Close()) }()
+
+		target, err := cmd.Flags().GetString("target")
+		if err != nil {
+			return err
+		}
+		out, err := cmd.Flags().GetString("out")
+		if err != nil {
+			return err
+		}
+		interval, err := cmd.Flags().GetDuration("interval")
+		if err != nil {
+			return err
+		}
+
+		registry := prometheus.NewRegistry()
+		registry.MustRegister(hubExporterFactory.New(target))
+
+		// WriteToTextfile writes to a temporary file on the same directory, then renames it, so
+		// the textfile collector never sees a partially written file.
+		return runInterval(cmd.Context(), interval, func(ctx context.Context) error {
+			if err := prometheus.WriteToTextfile(out, registry); err != nil {
+				return err
+			}
+			logger.Info("Wrote metrics", "out", out)
+			return nil
+		})
+	}),
+}
+
+func init() {
+	TextfileCmd.Flags().String("target", "", "Address of the Hub to probe")
+	if err := TextfileCmd.MarkFlagRequired("target"); err != nil {
+		panic(err)
+	}
+	TextfileCmd.Flags().String("out", "", "Path of the .prom file to write (eg: /var/lib/node_exporter/hub6.prom)")
+	if err := TextfileCmd.MarkFlagRequired("out"); err != nil {
+		panic(err)
+	}
+	TextfileCmd.Flags().Duration("interval", 0, "Rewrite the file at this interval; if 0, write it once and exit")
+
+	addHubExporterFlags(TextfileCmd)
+
+	RootCmd.AddCommand(TextfileCmd)
+}
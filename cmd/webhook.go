@@ -0,0 +1,40 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/fornellas/virginmedia_hub6_exporter/events"
+	"github.com/fornellas/virginmedia_hub6_exporter/webhook"
+)
+
+// addWebhookFlags adds the flags used by newWebhookNotifiers to cmd.
+func addWebhookFlags(cmd *cobra.Command) {
+	cmd.Flags().StringArray("webhook", nil, "Notify reboots, status, network access and provisioning changes, and channels losing lock to a webhook, given as FORMAT=URL, where FORMAT is one of generic, slack, ntfy or gotify; may be repeated. As URLs often contain secrets, prefer setting these with the VM_HUB6_EXPORTER_WEBHOOK environment variable, separated by whitespace")
+}
+
+// newWebhookNotifiers reads the flags added by addWebhookFlags, and creates a webhook.Notifier
+// for each webhook. On error, notifiers already created are closed.
+func newWebhookNotifiers(cmd *cobra.Command) ([]events.Sink, error) {
+	webhooks, err := cmd.Flags().GetStringArray("webhook")
+	if err != nil {
+		return nil, err
+	}
+
+	var sinks events.Sinks
+	for _, w := range webhooks {
+		formatName, url, ok := strings.Cut(w, "=")
+		if !ok {
+			return nil, errors.Join(errors.New("invalid --webhook, expected FORMAT=URL"), sinks.Close())
+		}
+		format, err := webhook.ParseFormat(formatName)
+		if err != nil {
+			return nil, errors.Join(err, sinks.Close())
+		}
+		sinks = append(sinks, webhook.NewNotifier(url, format, 10*time.Second))
+	}
+	return sinks, nil
+}
@@ -0,0 +1,107 @@
+package events
+
+import (
+	"errors"
+	"time"
+)
+
+// Severity of an event, with the same values as syslog severities.
+type Severity int
+
+const (
+	Emergency Severity = iota
+	Alert
+	Critical
+	Error
+	Warning
+	Notice
+	Informational
+	Debug
+)
+
+var severityNames = []string{
+	"emergency", "alert", "critical", "error", "warning", "notice", "informational", "debug",
+}
+
+func (s Severity) String() string {
+	if s < 0 || int(s) >= len(severityNames) {
+		return "unknown"
+	}
+	return severityNames[s]
+}
+
+// ParseSeverity returns the Severity named name, as returned by Severity.String, with
+// Informational for unknown names.
+func ParseSeverity(name string) Severity {
+	for i, n := range severityNames {
+		if n == name {
+			return Severity(i)
+		}
+	}
+	return Informational
+}
+
+// Event types
+const (
+	Reboot               = "reboot"
+	StatusChanged        = "status_changed"
+	AccessAllowedChanged = "access_allowed_changed"
+	LockLost             = "lock_lost"
+	LockAcquired         = "lock_acquired"
+	LineupChanged        = "lineup_changed"
+	EventLog             = "eventlog"
+	ProvisioningChanged  = "provisioning_changed"
+)
+
+// Param is a named value of an Event.
+type Param struct {
+	Name  string
+	Value string
+}
+
+// Event is something which happened to a Hub.
+type Event struct {
+	Time time.Time
+	// Address of the Hub
+	Target   string
+	Type     string
+	Severity Severity
+	// Human readable description
+	Message string
+	// Details, such as channel IDs and values
+	Params []Param
+}
+
+// Param returns the value of the param named name, or an empty string if it doesn't exist.
+func (e Event) Param(name string) string {
+	for _, p := range e.Params {
+		if p.Name == name {
+			return p.Value
+		}
+	}
+	return ""
+}
+
+// Sink receives events. Send must not block, as it is called while collecting metrics.
+type Sink interface {
+	Send(Event)
+	// Close delivers pending events, and releases resources.
+	Close() error
+}
+
+// Sinks sends events to all of its sinks.
+type Sinks []Sink
+
+func (s Sinks) Send(e Event) {
+	for _, sink := range s {
+		sink.Send(e)
+	}
+}
+
+func (s Sinks) Close() error {
+	var errs []error
+	for _, sink := range s {
+		errs = append(errs, sink.Close())
+	}
+	return errors.Join(errs...)
+}
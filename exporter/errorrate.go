@@ -0,0 +1,152 @@
+package exporter
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	hub6 "github.com/fornellas/virginmedia_hub6_exporter/hub6"
+)
+
+type errorSample struct {
+	time        time.Time
+	corrected   uint64
+	uncorrected uint64
+	// nil if not reported by the Hub
+	unerrored *uint64
+}
+
+// errorRates between two samples of a channel.
+type errorRates struct {
+	correctedPerSecond   float64
+	uncorrectedPerSecond float64
+	// Whether the ratios below are known, which requires unerrored codewords
+	ratiosValid bool
+	// Ratio of codewords which were corrected / uncorrectable
+	correctedRatio   float64
+	uncorrectedRatio float64
+}
+
+func newErrorRates(previous, current errorSample) (errorRates, bool) {
+	seconds := current.time.Sub(previous.time).Seconds()
+	// Counters reset when the modem reboots, which makes the rate unknown for this interval
+	if seconds <= 0 || current.corrected < previous.corrected || current.uncorrected < previous.uncorrected {
+		return errorRates{}, false
+	}
+
+	corrected := current.corrected - previous.corrected
+	uncorrected := current.uncorrected - previous.uncorrected
+	rates := errorRates{
+		correctedPerSecond:   float64(corrected) / seconds,
+		uncorrectedPerSecond: float64(uncorrected) / seconds,
+	}
+
+	if previous.unerrored != nil && current.unerrored != nil && *current.unerrored >= *previous.unerrored {
+		codewords := *current.unerrored - *previous.unerrored + corrected + uncorrected
+		if codewords > 0 {
+			rates.ratiosValid = true
+			rates.correctedRatio = float64(corrected) / float64(codewords)
+			rates.uncorrectedRatio = float64(uncorrected) / float64(codewords)
+		}
+	}
+
+	return rates, true
+}
+
+// errorRateTracker keeps the previous error counters of each downstream channel, to derive
+// error rates between scrapes.
+type errorRateTracker struct {
+	mu      sync.Mutex
+	samples map[uint64]errorSample
+}
+
+// update records the error counters of all current channels, sampled at now, and returns the
+// error rates for channels which also had a usable previous sample.
+func (t *errorRateTracker) update(now time.Time, channels []hub6.DownstreamChannel) map[uint64]errorRates {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	samples := map[uint64]errorSample{}
+	rates := map[uint64]errorRates{}
+	for _, c := range channels {
+		current := errorSample{
+			time:        now,
+			corrected:   c.CorrectedErrors,
+			uncorrected: c.UncorrectedErrors,
+			unerrored:   c.UnerroredCodewords,
+		}
+		samples[c.ChannelId] = current
+		if previous, ok := t.samples[c.ChannelId]; ok {
+			if r, ok := newErrorRates(previous, current); ok {
+				rates[c.ChannelId] = r
+			}
+		}
+	}
+	t.samples = samples
+
+	return rates
+}
+
+// collectErrorRates exports downstream error rates between the previous scrape and this one.
+func (e *HubExporter) collectErrorRates(ch chan<- prometheus.Metric, s *Scrape) {
+	if s.Downstream == nil {
+		return
+	}
+	channels := s.Downstream.DownstreamItem.DownstreamChannels
+	rates := e.errorRateTracker.update(s.Time, channels)
+	for _, c := range channels {
+		r, ok := rates[c.ChannelId]
+		if !ok {
+			continue
+		}
+		labels := []string{strconv.FormatUint(c.ChannelId, 10), c.ChannelType, c.Modulation}
+		ch <- prometheus.MustNewConstMetric(e.descDownstreamCorrectedRate, prometheus.GaugeValue, r.correctedPerSecond, labels...)
+		ch <- prometheus.MustNewConstMetric(e.descDownstreamUncorrectedRate, prometheus.GaugeValue, r.uncorrectedPerSecond, labels...)
+		if r.ratiosValid {
+			ch <- prometheus.MustNewConstMetric(e.descDownstreamCorrectedRatio, prometheus.GaugeValue, r.correctedRatio, labels...)
+			ch <- prometheus.MustNewConstMetric(e.descDownstreamUncorrectedRatio, prometheus.GaugeValue, r.uncorrectedRatio, labels...)
+		}
+	}
+}
+
+// ErrorSampleState is the persistent state of the error counters of a channel.
+type ErrorSampleState struct {
+	Time        time.Time `json:"time"`
+	Corrected   uint64    `json:"corrected"`
+	Uncorrected uint64    `json:"uncorrected"`
+	Unerrored   *uint64   `json:"unerrored,omitempty"`
+}
+
+// ErrorRateState is the persistent state of error rate derivation, by channel ID.
+type ErrorRateState map[uint64]ErrorSampleState
+
+func (t *errorRateTracker) state() ErrorRateState {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s := ErrorRateState{}
+	for id, sample := range t.samples {
+		s[id] = ErrorSampleState{
+			Time:        sample.time,
+			Corrected:   sample.corrected,
+			Uncorrected: sample.uncorrected,
+			Unerrored:   sample.unerrored,
+		}
+	}
+	return s
+}
+
+func (t *errorRateTracker) restore(s ErrorRateState) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.samples = map[uint64]errorSample{}
+	for id, sample := range s {
+		t.samples[id] = errorSample{
+			time:        sample.Time,
+			corrected:   sample.Corrected,
+			uncorrected: sample.Uncorrected,
+			unerrored:   sample.Unerrored,
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package exporter
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	hub6 "github.com/fornellas/virginmedia_hub6_exporter/hub6"
+)
+
+func uint64Ptr(v uint64) *uint64 {
+	return &v
+}
+
+func TestNewErrorRates(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	for _, tc := range []struct {
+		name     string
+		previous errorSample
+		current  errorSample
+		expected errorRates
+		ok       bool
+	}{
+		{
+			name:     "without unerrored codewords",
+			previous: errorSample{time: start, corrected: 100, uncorrected: 10},
+			current:  errorSample{time: start.Add(10 * time.Second), corrected: 150, uncorrected: 15},
+			expected: errorRates{correctedPerSecond: 5, uncorrectedPerSecond: 0.5},
+			ok:       true,
+		},
+		{
+			name:     "with unerrored codewords",
+			previous: errorSample{time: start, corrected: 100, uncorrected: 10, unerrored: uint64Ptr(1000)},
+			current:  errorSample{time: start.Add(10 * time.Second), corrected: 130, uncorrected: 20, unerrored: uint64Ptr(1960)},
+			expected: errorRates{
+				correctedPerSecond:   3,
+				uncorrectedPerSecond: 1,
+				ratiosValid:          true,
+				correctedRatio:       0.03,
+				uncorrectedRatio:     0.01,
+			},
+			ok: true,
+		},
+		{
+			name:     "no codewords",
+			previous: errorSample{time: start, unerrored: uint64Ptr(1000)},
+			current:  errorSample{time: start.Add(10 * time.Second), unerrored: uint64Ptr(1000)},
+			expected: errorRates{},
+			ok:       true,
+		},
+		{
+			name:     "unerrored codewords reset",
+			previous: errorSample{time: start, corrected: 100, unerrored: uint64Ptr(1000)},
+			current:  errorSample{time: start.Add(10 * time.Second), corrected: 110, unerrored: uint64Ptr(10)},
+			expected: errorRates{correctedPerSecond: 1},
+			ok:       true,
+		},
+		{
+			name:     "corrected reset",
+			previous: errorSample{time: start, corrected: 100, uncorrected: 10},
+			current:  errorSample{time: start.Add(10 * time.Second), corrected: 5, uncorrected: 10},
+		},
+		{
+			name:     "uncorrected reset",
+			previous: errorSample{time: start, corrected: 100, uncorrected: 10},
+			current:  errorSample{time: start.Add(10 * time.Second), corrected: 100, uncorrected: 0},
+		},
+		{
+			name:     "same time",
+			previous: errorSample{time: start, corrected: 100},
+			current:  errorSample{time: start, corrected: 110},
+		},
+	} {
+		rates, ok := newErrorRates(tc.previous, tc.current)
+		if ok != tc.ok {
+			t.Errorf("%s: got ok %v, expected %v", tc.name, ok, tc.ok)
+			continue
+		}
+		if rates != tc.expected {
+			t.Errorf("%s: got %+v, expected %+v", tc.name, rates, tc.expected)
+		}
+	}
+}
+
+func TestErrorRateTrackerUpdate(t *testing.T) {
+	var tracker errorRateTracker
+	start := time.Unix(1700000000, 0)
+
+	// Rates are unknown on the first scrape
+	rates := tracker.update(start, []hub6.DownstreamChannel{
+		{ChannelId: 1, CorrectedErrors: 100, UncorrectedErrors: 10},
+		{ChannelId: 2, CorrectedErrors: 200, UncorrectedErrors: 20},
+	})
+	if len(rates) != 0 {
+		t.Fatalf("first scrape: got %v, expected no rates", rates)
+	}
+
+	// Channel 1 counters reset, channel 2 is gone and channel 3 is new
+	rates = tracker.update(start.Add(10*time.Second), []hub6.DownstreamChannel{
+		{ChannelId: 1, CorrectedErrors: 20, UncorrectedErrors: 0},
+		{ChannelId: 3, CorrectedErrors: 300, UncorrectedErrors: 30},
+	})
+	if len(rates) != 0 {
+		t.Fatalf("got %v, expected no rates", rates)
+	}
+
+	// Rates resume from the samples after the reset
+	rates = tracker.update(start.Add(20*time.Second), []hub6.DownstreamChannel{
+		{ChannelId: 1, CorrectedErrors: 70, UncorrectedErrors: 10},
+		{ChannelId: 2, CorrectedErrors: 250, UncorrectedErrors: 25},
+		{ChannelId: 3, CorrectedErrors: 300, UncorrectedErrors: 30},
+	})
+	expected := map[uint64]errorRates{
+		1: {correctedPerSecond: 5, uncorrectedPerSecond: 1},
+		3: {},
+	}
+	if !reflect.DeepEqual(rates, expected) {
+		t.Errorf("got %+v, expected %+v", rates, expected)
+	}
+}
+
+func TestErrorRateTrackerRestore(t *testing.T) {
+	var tracker errorRateTracker
+	start := time.Unix(1700000000, 0)
+	tracker.update(start, []hub6.DownstreamChannel{
+		{ChannelId: 1, CorrectedErrors: 100, UncorrectedErrors: 10, UnerroredCodewords: uint64Ptr(1000)},
+	})
+
+	var restored errorRateTracker
+	restored.restore(tracker.state())
+	if !reflect.DeepEqual(restored.state(), tracker.state()) {
+		t.Fatalf("got state %+v, expected %+v", restored.state(), tracker.state())
+	}
+
+	rates := restored.update(start.Add(10*time.Second), []hub6.DownstreamChannel{
+		{ChannelId: 1, CorrectedErrors: 130, UncorrectedErrors: 20, UnerroredCodewords: uint64Ptr(1960)},
+	})
+	expected := map[uint64]errorRates{1: {
+		correctedPerSecond:   3,
+		uncorrectedPerSecond: 1,
+		ratiosValid:          true,
+		correctedRatio:       0.03,
+		uncorrectedRatio:     0.01,
+	}}
+	if !reflect.DeepEqual(rates, expected) {
+		t.Errorf("got %+v, expected %+v", rates, expected)
+	}
+}
@@ -0,0 +1,170 @@
+package exporter
+
+import (
+	"log/slog"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/fornellas/virginmedia_hub6_exporter/events"
+	hub6 "github.com/fornellas/virginmedia_hub6_exporter/hub6"
+)
+
+// eventLogKey identifies a counter of event log events.
+type eventLogKey struct {
+	priority string
+	code     string
+}
+
+// eventLogTracker detects entries added to the event log between scrapes. The Hub keeps a
+// rolling window of the latest entries, so entries are deduplicated against the previous
+// scrape: an entry is identified by all of its fields, and identical entries (eg: repeated
+// before the Hub has a time) by how many times they occur. Entries already in the event log
+// on the first scrape are not counted, as they may have been counted before a restart without
+// persisted state.
+//
+// As only the previous scrape is kept, an entry which drops out of the event log and shows up
+// again in a later scrape is counted again.
+type eventLogTracker struct {
+	mu sync.Mutex
+	// Number of times each entry occurred on the previous scrape; nil before the first scrape
+	seen map[hub6.EventLogEntry]int
+	// Total events by priority and code
+	totals map[eventLogKey]uint64
+}
+
+// update records all current entries, and returns the ones not seen on the previous scrape,
+// or seen fewer times, along with the total events so far.
+func (t *eventLogTracker) update(entries []hub6.EventLogEntry) ([]hub6.EventLogEntry, map[eventLogKey]uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.totals == nil {
+		t.totals = map[eventLogKey]uint64{}
+	}
+
+	first := t.seen == nil
+	var added []hub6.EventLogEntry
+	seen := map[hub6.EventLogEntry]int{}
+	for _, entry := range entries {
+		seen[entry]++
+		if first || seen[entry] <= t.seen[entry] {
+			continue
+		}
+		added = append(added, entry)
+		event := entry.Event()
+		t.totals[eventLogKey{priority: event.Priority, code: event.Code}]++
+	}
+	t.seen = seen
+
+	totals := make(map[eventLogKey]uint64, len(t.totals))
+	for k, v := range t.totals {
+		totals[k] = v
+	}
+	return added, totals
+}
+
+// collectEventLog exports the number of event log events, and optionally logs new ones.
+func (e *HubExporter) collectEventLog(ch chan<- prometheus.Metric, s *Scrape) {
+	up := 0.0
+	if s.EventLogErr != nil {
+		slog.Debug("Failed to fetch event log", "target", e.address, "err", s.EventLogErr)
+	}
+	if s.EventLog != nil {
+		up = 1.0
+		added, totals := e.eventLogTracker.update(s.EventLog.EventLogEntries)
+		for _, entry := range added {
+			event := entry.Event()
+			if e.eventLogForwarding {
+				slog.Info("Hub event log",
+					"target", e.address,
+					"time", event.Time,
+					"priority", event.Priority,
+					"code", event.Code,
+					"event_id", event.EventId,
+					"message", event.Message,
+				)
+			}
+			eventTime := s.Time
+			if !event.Time.IsZero() {
+				eventTime = event.Time
+			}
+			e.emit(eventTime, events.EventLog, events.ParseSeverity(event.Priority), event.Message,
+				events.Param{Name: "code", Value: event.Code},
+				events.Param{Name: "event_id", Value: strconv.FormatUint(event.EventId, 10)},
+				events.Param{Name: "priority", Value: event.Priority},
+			)
+		}
+		for k, v := range totals {
+			ch <- prometheus.MustNewConstMetric(e.descEventLogEvents, prometheus.CounterValue, float64(v), k.priority, k.code)
+		}
+	}
+	ch <- prometheus.MustNewConstMetric(e.descEventLogUp, prometheus.GaugeValue, up)
+}
+
+// EventLogCountState is the total of event log events with a priority and code.
+type EventLogCountState struct {
+	Priority string `json:"priority"`
+	Code     string `json:"code"`
+	Count    uint64 `json:"count"`
+}
+
+// EventLogState is the persistent state of event log event detection.
+type EventLogState struct {
+	// Entries seen on the previous scrape, repeated as many times as they occurred; null
+	// before the first scrape
+	Seen   []hub6.EventLogEntry `json:"seen"`
+	Totals []EventLogCountState `json:"totals"`
+}
+
+func (t *eventLogTracker) state() EventLogState {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var s EventLogState
+	if t.seen != nil {
+		// Distinguishes an empty event log from no scrape yet
+		s.Seen = []hub6.EventLogEntry{}
+	}
+	for entry, count := range t.seen {
+		for i := 0; i < count; i++ {
+			s.Seen = append(s.Seen, entry)
+		}
+	}
+	sort.Slice(s.Seen, func(i, j int) bool {
+		if s.Seen[i].Time != s.Seen[j].Time {
+			return s.Seen[i].Time < s.Seen[j].Time
+		}
+		if s.Seen[i].EventId != s.Seen[j].EventId {
+			return s.Seen[i].EventId < s.Seen[j].EventId
+		}
+		return s.Seen[i].Message < s.Seen[j].Message
+	})
+	for k, v := range t.totals {
+		s.Totals = append(s.Totals, EventLogCountState{Priority: k.priority, Code: k.code, Count: v})
+	}
+	sort.Slice(s.Totals, func(i, j int) bool {
+		if s.Totals[i].Priority != s.Totals[j].Priority {
+			return s.Totals[i].Priority < s.Totals[j].Priority
+		}
+		return s.Totals[i].Code < s.Totals[j].Code
+	})
+	return s
+}
+
+func (t *eventLogTracker) restore(s EventLogState) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.seen = nil
+	if s.Seen != nil {
+		t.seen = map[hub6.EventLogEntry]int{}
+		for _, entry := range s.Seen {
+			t.seen[entry]++
+		}
+	}
+	t.totals = map[eventLogKey]uint64{}
+	for _, c := range s.Totals {
+		t.totals[eventLogKey{priority: c.Priority, code: c.Code}] = c.Count
+	}
+}
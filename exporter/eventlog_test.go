@@ -0,0 +1,89 @@
+package exporter
+
+import (
+	"reflect"
+	"testing"
+
+	hub6 "github.com/fornellas/virginmedia_hub6_exporter/hub6"
+)
+
+func TestEventLogTrackerUpdate(t *testing.T) {
+	t3 := hub6.EventLogEntry{Priority: "critical", Time: "2026-01-02T03:04:05Z", Message: "T3 time-out", EventId: 82000200}
+	t4 := hub6.EventLogEntry{Priority: "critical", Time: "2026-01-02T03:05:05Z", Message: "T4 time-out", EventId: 82000300}
+	renew := hub6.EventLogEntry{Priority: "notice", Time: "2026-01-02T03:06:05Z", Message: "DHCP RENEW WARNING", EventId: 68010300}
+
+	var tracker eventLogTracker
+
+	// Entries already in the event log on the first scrape are not counted
+	added, totals := tracker.update([]hub6.EventLogEntry{t3, t4})
+	if len(added) != 0 || len(totals) != 0 {
+		t.Fatalf("first scrape: got %v %v, expected nothing", added, totals)
+	}
+
+	added, totals = tracker.update([]hub6.EventLogEntry{t3, t4, renew})
+	if !reflect.DeepEqual(added, []hub6.EventLogEntry{renew}) {
+		t.Errorf("got %v, expected %v", added, []hub6.EventLogEntry{renew})
+	}
+	expectedTotals := map[eventLogKey]uint64{{priority: "notice", code: "D103.0"}: 1}
+	if !reflect.DeepEqual(totals, expectedTotals) {
+		t.Errorf("got %v, expected %v", totals, expectedTotals)
+	}
+
+	// Entries rolling out of the event log are not counted
+	added, totals = tracker.update([]hub6.EventLogEntry{t4, renew})
+	if len(added) != 0 || !reflect.DeepEqual(totals, expectedTotals) {
+		t.Errorf("got %v %v, expected no added entries and %v", added, totals, expectedTotals)
+	}
+}
+
+func TestEventLogTrackerUpdateRepeated(t *testing.T) {
+	// Entries logged before the Hub has a time are identical
+	t3 := hub6.EventLogEntry{Priority: "critical", Message: "T3 time-out", EventId: 82000200}
+
+	var tracker eventLogTracker
+	tracker.update([]hub6.EventLogEntry{t3})
+
+	added, totals := tracker.update([]hub6.EventLogEntry{t3, t3, t3})
+	if !reflect.DeepEqual(added, []hub6.EventLogEntry{t3, t3}) {
+		t.Errorf("got %v, expected %v", added, []hub6.EventLogEntry{t3, t3})
+	}
+	expectedTotals := map[eventLogKey]uint64{{priority: "critical", code: "R02.0"}: 2}
+	if !reflect.DeepEqual(totals, expectedTotals) {
+		t.Errorf("got %v, expected %v", totals, expectedTotals)
+	}
+
+	// The oldest one rolled out of the event log
+	added, totals = tracker.update([]hub6.EventLogEntry{t3, t3})
+	if len(added) != 0 || !reflect.DeepEqual(totals, expectedTotals) {
+		t.Errorf("got %v %v, expected no added entries and %v", added, totals, expectedTotals)
+	}
+}
+
+func TestEventLogTrackerRestore(t *testing.T) {
+	t3 := hub6.EventLogEntry{Priority: "critical", Message: "T3 time-out", EventId: 82000200}
+
+	// State of an empty event log: entries added while the exporter was down are counted
+	var tracker eventLogTracker
+	tracker.update(nil)
+	var restored eventLogTracker
+	restored.restore(tracker.state())
+	if added, _ := restored.update([]hub6.EventLogEntry{t3}); len(added) != 1 {
+		t.Errorf("got %v, expected 1 added entry", added)
+	}
+
+	// Repeated entries are kept
+	tracker = eventLogTracker{}
+	tracker.update([]hub6.EventLogEntry{t3, t3})
+	restored = eventLogTracker{}
+	restored.restore(tracker.state())
+	if added, _ := restored.update([]hub6.EventLogEntry{t3, t3, t3}); len(added) != 1 {
+		t.Errorf("got %v, expected 1 added entry", added)
+	}
+
+	// State before the first scrape
+	restored = eventLogTracker{}
+	restored.restore((&eventLogTracker{}).state())
+	if added, _ := restored.update([]hub6.EventLogEntry{t3}); len(added) != 0 {
+		t.Errorf("got %v, expected no added entries", added)
+	}
+}
@@ -0,0 +1,30 @@
+package exporter
+
+import (
+	"time"
+
+	"github.com/fornellas/virginmedia_hub6_exporter/events"
+)
+
+// EventSink sets where detected events (eg: reboots) are sent to.
+func (e *HubExporter) EventSink(sink events.Sink) *HubExporter {
+	e.eventSink = sink
+	return e
+}
+
+// emit sends an event to the event sink, if set.
+func (e *HubExporter) emit(
+	t time.Time, eventType string, severity events.Severity, message string, params ...events.Param,
+) {
+	if e.eventSink == nil {
+		return
+	}
+	e.eventSink.Send(events.Event{
+		Time:     t,
+		Target:   e.address,
+		Type:     eventType,
+		Severity: severity,
+		Message:  message,
+		Params:   params,
+	})
+}
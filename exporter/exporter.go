@@ -1,24 +1,45 @@
 package exporter
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
+	"log/slog"
 	"strconv"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 
+	"github.com/fornellas/virginmedia_hub6_exporter/events"
+	"github.com/fornellas/virginmedia_hub6_exporter/health"
 	hub6 "github.com/fornellas/virginmedia_hub6_exporter/hub6"
+	"github.com/fornellas/virginmedia_hub6_exporter/plan"
 )
 
 // HubExporter collects metrics from a VirginMedia Hub 6 device.
 type HubExporter struct {
 	address string
-	client  *http.Client
+	client  *hub6.Client
+
+	healthThresholds health.Thresholds
+	// nil if no plan is declared
+	plan *plan.Plan
+
+	// State kept across scrapes
+	rebootTracker           rebootTracker
+	downstreamLineupTracker lineupTracker
+	upstreamLineupTracker   lineupTracker
+	errorRateTracker        errorRateTracker
+	timeoutTracker          timeoutTracker
+	eventLogTracker         eventLogTracker
+	transitionTracker       transitionTracker
+	provisioningTracker     provisioningTracker
+
+	// nil if events are not sent anywhere
+	eventSink events.Sink
+
+	lanHostInfo        bool
+	eventLogForwarding bool
 
 	// Descriptors
 	descDownstreamPower       *prometheus.Desc
@@ -29,6 +50,11 @@ type HubExporter struct {
 	descDownstreamLockStatus  *prometheus.Desc
 	descDownstreamFrequencyHz *prometheus.Desc
 
+	descDownstreamCorrectedRate    *prometheus.Desc
+	descDownstreamUncorrectedRate  *prometheus.Desc
+	descDownstreamCorrectedRatio   *prometheus.Desc
+	descDownstreamUncorrectedRatio *prometheus.Desc
+
 	descUpstreamPower       *prometheus.Desc
 	descUpstreamSymbolRate  *prometheus.Desc
 	descUpstreamLockStatus  *prometheus.Desc
@@ -38,11 +64,38 @@ type HubExporter struct {
 	descUpstreamT3          *prometheus.Desc
 	descUpstreamT4          *prometheus.Desc
 
+	descUpstreamTimeoutEvents *prometheus.Desc
+
 	descServiceMaxTrafficRate  *prometheus.Desc
 	descServiceMaxTrafficBurst *prometheus.Desc
 	descServiceMinReservedRate *prometheus.Desc
 	descServiceMaxConcatBurst  *prometheus.Desc
 
+	descDownstreamSummaryPowerMin    *prometheus.Desc
+	descDownstreamSummaryPowerMax    *prometheus.Desc
+	descDownstreamSummaryPowerAvg    *prometheus.Desc
+	descDownstreamSummaryPowerSpread *prometheus.Desc
+	descDownstreamSummarySnrMin      *prometheus.Desc
+	descDownstreamSummarySnrMax      *prometheus.Desc
+	descDownstreamSummarySnrAvg      *prometheus.Desc
+	descDownstreamSummaryChannels    *prometheus.Desc
+	descDownstreamSummaryLocked      *prometheus.Desc
+	descDownstreamSummaryCorrected   *prometheus.Desc
+	descDownstreamSummaryUncorrected *prometheus.Desc
+
+	descSpectrumTilt      *prometheus.Desc
+	descSpectrumSuckouts  *prometheus.Desc
+	descSpectrumMaxDip    *prometheus.Desc
+	descSpectrumRipple    *prometheus.Desc
+	descSpectrumRippleRms *prometheus.Desc
+
+	descUpstreamSummaryPowerMin    *prometheus.Desc
+	descUpstreamSummaryPowerMax    *prometheus.Desc
+	descUpstreamSummaryPowerAvg    *prometheus.Desc
+	descUpstreamSummaryPowerSpread *prometheus.Desc
+	descUpstreamSummaryChannels    *prometheus.Desc
+	descUpstreamSummaryLocked      *prometheus.Desc
+
 	descCableInfo            *prometheus.Desc
 	descCableStatus          *prometheus.Desc
 	descCableUptimeSeconds   *prometheus.Desc
@@ -50,11 +103,43 @@ type HubExporter struct {
 	descCableMaxCPEs         *prometheus.Desc
 	descCableBaselinePrivacy *prometheus.Desc
 
+	descReboots             *prometheus.Desc
+	descLastRebootTimestamp *prometheus.Desc
+	descLineupChanges       *prometheus.Desc
+	descChannelHealth       *prometheus.Desc
+
 	// Per-endpoint up metrics (1 = endpoint scraped successfully, 0 = failure)
 	descDownstreamUp   *prometheus.Desc
 	descUpstreamUp     *prometheus.Desc
 	descServiceFlowsUp *prometheus.Desc
 	descStateUp        *prometheus.Desc
+
+	descLastScrapeTimestamp *prometheus.Desc
+
+	descLanHostsUp   *prometheus.Desc
+	descLanHosts     *prometheus.Desc
+	descLanHostInfo  *prometheus.Desc
+	descLanHostRssi  *prometheus.Desc
+	descLanHostSpeed *prometheus.Desc
+
+	descProvisioningChanges *prometheus.Desc
+	descTierInfo            *prometheus.Desc
+
+	descPlanRate         *prometheus.Desc
+	descPlanRateRatio    *prometheus.Desc
+	descPlanRateMismatch *prometheus.Desc
+
+	descEventLogUp     *prometheus.Desc
+	descEventLogEvents *prometheus.Desc
+
+	descWifiUp             *prometheus.Desc
+	descWifiRadioInfo      *prometheus.Desc
+	descWifiRadioEnabled   *prometheus.Desc
+	descWifiRadioChannel   *prometheus.Desc
+	descWifiRadioBandwidth *prometheus.Desc
+	descWifiRadioTxPower   *prometheus.Desc
+	descWifiRadioSsids     *prometheus.Desc
+	descWifiRadioClients   *prometheus.Desc
 }
 
 // NewHubExporter creates a new exporter that will query the hub at address.
@@ -66,7 +151,9 @@ func NewHubExporter(address string, timeout time.Duration) *HubExporter {
 
 	return &HubExporter{
 		address: address,
-		client:  &http.Client{Timeout: timeout},
+		client:  hub6.NewClient(address, timeout),
+
+		healthThresholds: health.DefaultThresholds(),
 
 		descDownstreamPower: prometheus.NewDesc(
 			"virginmedia_hub6_downstream_power_dbmv",
@@ -104,6 +191,27 @@ func NewHubExporter(address string, timeout time.Duration) *HubExporter {
 			labelsDS, nil,
 		),
 
+		descDownstreamCorrectedRate: prometheus.NewDesc(
+			"virginmedia_hub6_downstream_corrected_errors_per_second",
+			"Downstream channel corrected RS errors per second since the previous scrape",
+			labelsDS, nil,
+		),
+		descDownstreamUncorrectedRate: prometheus.NewDesc(
+			"virginmedia_hub6_downstream_uncorrected_errors_per_second",
+			"Downstream channel uncorrected RS errors per second since the previous scrape",
+			labelsDS, nil,
+		),
+		descDownstreamCorrectedRatio: prometheus.NewDesc(
+			"virginmedia_hub6_downstream_corrected_codewords_ratio",
+			"Ratio of downstream channel codewords which were corrected since the previous scrape (only when the Hub reports unerrored codewords)",
+			labelsDS, nil,
+		),
+		descDownstreamUncorrectedRatio: prometheus.NewDesc(
+			"virginmedia_hub6_downstream_uncorrected_codewords_ratio",
+			"Ratio of downstream channel codewords which were uncorrectable since the previous scrape (only when the Hub reports unerrored codewords)",
+			labelsDS, nil,
+		),
+
 		descUpstreamPower: prometheus.NewDesc(
 			"virginmedia_hub6_upstream_power_dbmv",
 			"Upstream channel power in dBmV",
@@ -145,6 +253,12 @@ func NewHubExporter(address string, timeout time.Duration) *HubExporter {
 			labelsUS, nil,
 		),
 
+		descUpstreamTimeoutEvents: prometheus.NewDesc(
+			"virginmedia_hub6_upstream_timeout_events_total",
+			"Number of upstream timeouts detected by this exporter from increments between scrapes, across all channels",
+			[]string{"type"}, nil,
+		),
+
 		descServiceMaxTrafficRate: prometheus.NewDesc(
 			"virginmedia_hub6_serviceflow_max_traffic_rate_bps",
 			"ServiceFlow max traffic rate in bps",
@@ -166,6 +280,119 @@ func NewHubExporter(address string, timeout time.Duration) *HubExporter {
 			labelsSF, nil,
 		),
 
+		descDownstreamSummaryPowerMin: prometheus.NewDesc(
+			"virginmedia_hub6_downstream_summary_power_min_dbmv",
+			"Minimum downstream channel power in dBmV",
+			nil, nil,
+		),
+		descDownstreamSummaryPowerMax: prometheus.NewDesc(
+			"virginmedia_hub6_downstream_summary_power_max_dbmv",
+			"Maximum downstream channel power in dBmV",
+			nil, nil,
+		),
+		descDownstreamSummaryPowerAvg: prometheus.NewDesc(
+			"virginmedia_hub6_downstream_summary_power_avg_dbmv",
+			"Average downstream channel power in dBmV",
+			nil, nil,
+		),
+		descDownstreamSummaryPowerSpread: prometheus.NewDesc(
+			"virginmedia_hub6_downstream_summary_power_spread_db",
+			"Difference between maximum and minimum downstream channel power in dB",
+			nil, nil,
+		),
+		descDownstreamSummarySnrMin: prometheus.NewDesc(
+			"virginmedia_hub6_downstream_summary_snr_min_db",
+			"Minimum downstream channel SNR (RxMER for OFDM) in dB",
+			nil, nil,
+		),
+		descDownstreamSummarySnrMax: prometheus.NewDesc(
+			"virginmedia_hub6_downstream_summary_snr_max_db",
+			"Maximum downstream channel SNR (RxMER for OFDM) in dB",
+			nil, nil,
+		),
+		descDownstreamSummarySnrAvg: prometheus.NewDesc(
+			"virginmedia_hub6_downstream_summary_snr_avg_db",
+			"Average downstream channel SNR (RxMER for OFDM) in dB",
+			nil, nil,
+		),
+		descDownstreamSummaryChannels: prometheus.NewDesc(
+			"virginmedia_hub6_downstream_summary_channels",
+			"Number of downstream channels",
+			[]string{"channel_type"}, nil,
+		),
+		descDownstreamSummaryLocked: prometheus.NewDesc(
+			"virginmedia_hub6_downstream_summary_locked_channels",
+			"Number of locked downstream channels",
+			[]string{"channel_type"}, nil,
+		),
+		descDownstreamSummaryCorrected: prometheus.NewDesc(
+			"virginmedia_hub6_downstream_summary_corrected_errors",
+			"Total corrected RS errors across all downstream channels",
+			nil, nil,
+		),
+		descDownstreamSummaryUncorrected: prometheus.NewDesc(
+			"virginmedia_hub6_downstream_summary_uncorrected_errors",
+			"Total uncorrected RS errors across all downstream channels",
+			nil, nil,
+		),
+
+		descSpectrumTilt: prometheus.NewDesc(
+			"virginmedia_hub6_downstream_spectrum_tilt_db_per_100mhz",
+			"Downstream SC-QAM power tilt in dB per 100 MHz, from a linear regression of power over frequency",
+			nil, nil,
+		),
+		descSpectrumSuckouts: prometheus.NewDesc(
+			"virginmedia_hub6_downstream_spectrum_suckouts",
+			"Number of downstream SC-QAM channels with power at least 3 dB below the average of their neighbours",
+			nil, nil,
+		),
+		descSpectrumMaxDip: prometheus.NewDesc(
+			"virginmedia_hub6_downstream_spectrum_max_dip_db",
+			"Largest downstream SC-QAM channel power dip vs the average of its neighbours in dB",
+			nil, nil,
+		),
+		descSpectrumRipple: prometheus.NewDesc(
+			"virginmedia_hub6_downstream_spectrum_ripple_db",
+			"Peak-to-peak downstream SC-QAM power ripple after removing tilt in dB",
+			nil, nil,
+		),
+		descSpectrumRippleRms: prometheus.NewDesc(
+			"virginmedia_hub6_downstream_spectrum_ripple_rms_db",
+			"Root mean square downstream SC-QAM power ripple after removing tilt in dB",
+			nil, nil,
+		),
+
+		descUpstreamSummaryPowerMin: prometheus.NewDesc(
+			"virginmedia_hub6_upstream_summary_power_min_dbmv",
+			"Minimum upstream channel power in dBmV",
+			nil, nil,
+		),
+		descUpstreamSummaryPowerMax: prometheus.NewDesc(
+			"virginmedia_hub6_upstream_summary_power_max_dbmv",
+			"Maximum upstream channel power in dBmV",
+			nil, nil,
+		),
+		descUpstreamSummaryPowerAvg: prometheus.NewDesc(
+			"virginmedia_hub6_upstream_summary_power_avg_dbmv",
+			"Average upstream channel power in dBmV",
+			nil, nil,
+		),
+		descUpstreamSummaryPowerSpread: prometheus.NewDesc(
+			"virginmedia_hub6_upstream_summary_power_spread_db",
+			"Difference between maximum and minimum upstream channel power in dB",
+			nil, nil,
+		),
+		descUpstreamSummaryChannels: prometheus.NewDesc(
+			"virginmedia_hub6_upstream_summary_channels",
+			"Number of upstream channels",
+			[]string{"channel_type"}, nil,
+		),
+		descUpstreamSummaryLocked: prometheus.NewDesc(
+			"virginmedia_hub6_upstream_summary_locked_channels",
+			"Number of locked upstream channels",
+			[]string{"channel_type"}, nil,
+		),
+
 		descCableInfo: prometheus.NewDesc(
 			"virginmedia_hub6_info",
 			"Cable modem info labels (value is always 1)",
@@ -197,6 +424,27 @@ func NewHubExporter(address string, timeout time.Duration) *HubExporter {
 			[]string{}, nil,
 		),
 
+		descReboots: prometheus.NewDesc(
+			"virginmedia_hub6_reboots_total",
+			"Number of cable modem reboots detected by this exporter, derived from uptime resets",
+			nil, nil,
+		),
+		descLastRebootTimestamp: prometheus.NewDesc(
+			"virginmedia_hub6_last_reboot_timestamp_seconds",
+			"Unix timestamp of the last cable modem reboot, derived from its uptime",
+			nil, nil,
+		),
+		descLineupChanges: prometheus.NewDesc(
+			"virginmedia_hub6_channel_lineup_changes_total",
+			"Number of channel lineup changes detected by this exporter (change is added, removed or frequency_changed)",
+			[]string{"direction", "change"}, nil,
+		),
+		descChannelHealth: prometheus.NewDesc(
+			"virginmedia_hub6_channel_health",
+			"Channel signal quality graded against DOCSIS thresholds (0 = ok, 1 = warn, 2 = critical)",
+			[]string{"direction", "channel_id"}, nil,
+		),
+
 		// per-endpoint up metrics
 		descDownstreamUp: prometheus.NewDesc(
 			"virginmedia_hub6_downstream_up",
@@ -218,9 +466,152 @@ func NewHubExporter(address string, timeout time.Duration) *HubExporter {
 			"Whether the state endpoint was scraped successfully (1 = up, 0 = down)",
 			nil, nil,
 		),
+
+		descLastScrapeTimestamp: prometheus.NewDesc(
+			"virginmedia_hub6_last_scrape_timestamp_seconds",
+			"Unix timestamp of when the Hub was scraped",
+			nil, nil,
+		),
+
+		descLanHostsUp: prometheus.NewDesc(
+			"virginmedia_hub6_lan_hosts_up",
+			"Whether the hosts endpoint was scraped successfully (1 = up, 0 = down); only scraped when logging in to the Hub",
+			nil, nil,
+		),
+		descLanHosts: prometheus.NewDesc(
+			"virginmedia_hub6_lan_hosts",
+			"Number of hosts connected to the Hub LAN",
+			[]string{"interface"}, nil,
+		),
+		descLanHostInfo: prometheus.NewDesc(
+			"virginmedia_hub6_lan_host_info",
+			"Information about each host connected to the Hub LAN",
+			[]string{"mac_address", "hostname", "ip_address", "interface", "band", "ssid"}, nil,
+		),
+		descLanHostRssi: prometheus.NewDesc(
+			"virginmedia_hub6_lan_host_rssi_dbm",
+			"Wi-Fi RSSI of each host connected to the Hub LAN in dBm",
+			[]string{"mac_address"}, nil,
+		),
+		descLanHostSpeed: prometheus.NewDesc(
+			"virginmedia_hub6_lan_host_speed_mbps",
+			"Link speed of each host connected to the Hub LAN in Mbps",
+			[]string{"mac_address"}, nil,
+		),
+
+		descProvisioningChanges: prometheus.NewDesc(
+			"virginmedia_hub6_provisioning_changes_total",
+			"Number of provisioning changes (boot filename or primary service flow max traffic rate) detected by this exporter",
+			[]string{"field"}, nil,
+		),
+		descTierInfo: prometheus.NewDesc(
+			"virginmedia_hub6_tier_info",
+			"Provisioned configuration parsed from the boot filename",
+			[]string{"boot_filename", "model", "segment", "tier", "wifi", "ip_mode"}, nil,
+		),
+
+		descPlanRate: prometheus.NewDesc(
+			"virginmedia_hub6_plan_rate_bps",
+			"Declared plan rate in bps",
+			[]string{"direction"}, nil,
+		),
+		descPlanRateRatio: prometheus.NewDesc(
+			"virginmedia_hub6_plan_rate_ratio",
+			"Ratio between the primary service flow max traffic rate and the declared plan rate",
+			[]string{"direction"}, nil,
+		),
+		descPlanRateMismatch: prometheus.NewDesc(
+			"virginmedia_hub6_plan_rate_mismatch",
+			"Whether the primary service flow max traffic rate is below the declared plan rate (1 = below, 0 = ok)",
+			[]string{"direction"}, nil,
+		),
+
+		descEventLogUp: prometheus.NewDesc(
+			"virginmedia_hub6_eventlog_up",
+			"Whether the eventlog endpoint was scraped successfully (1 = up, 0 = down)",
+			nil, nil,
+		),
+		descEventLogEvents: prometheus.NewDesc(
+			"virginmedia_hub6_eventlog_events_total",
+			"Number of cable modem event log entries seen by this exporter, deduplicated across scrapes",
+			[]string{"priority", "code"}, nil,
+		),
+
+		descWifiUp: prometheus.NewDesc(
+			"virginmedia_hub6_wifi_up",
+			"Whether the Wi-Fi radios endpoint was scraped successfully (1 = up, 0 = down); only scraped when logging in to the Hub",
+			nil, nil,
+		),
+		descWifiRadioInfo: prometheus.NewDesc(
+			"virginmedia_hub6_wifi_radio_info",
+			"Information about each Wi-Fi radio",
+			[]string{"id", "band", "status", "mode", "auto_channel"}, nil,
+		),
+		descWifiRadioEnabled: prometheus.NewDesc(
+			"virginmedia_hub6_wifi_radio_enabled",
+			"Whether the Wi-Fi radio is enabled (1 = enabled, 0 = disabled)",
+			[]string{"id", "band"}, nil,
+		),
+		descWifiRadioChannel: prometheus.NewDesc(
+			"virginmedia_hub6_wifi_radio_channel",
+			"Wi-Fi radio channel number",
+			[]string{"id", "band"}, nil,
+		),
+		descWifiRadioBandwidth: prometheus.NewDesc(
+			"virginmedia_hub6_wifi_radio_bandwidth_mhz",
+			"Wi-Fi radio channel bandwidth in MHz",
+			[]string{"id", "band"}, nil,
+		),
+		descWifiRadioTxPower: prometheus.NewDesc(
+			"virginmedia_hub6_wifi_radio_transmit_power_percent",
+			"Wi-Fi radio transmit power in percent of the maximum",
+			[]string{"id", "band"}, nil,
+		),
+		descWifiRadioSsids: prometheus.NewDesc(
+			"virginmedia_hub6_wifi_radio_ssids",
+			"Number of SSIDs enabled on the Wi-Fi radio",
+			[]string{"id", "band"}, nil,
+		),
+		descWifiRadioClients: prometheus.NewDesc(
+			"virginmedia_hub6_wifi_radio_clients",
+			"Number of clients connected to the Wi-Fi radio",
+			[]string{"id", "band"}, nil,
+		),
 	}
 }
 
+// LanHostInfo enables exporting per host metrics for each host connected to the Hub LAN. These
+// are only available when logging in to the Hub, see Password.
+func (e *HubExporter) LanHostInfo(enabled bool) *HubExporter {
+	e.lanHostInfo = enabled
+	return e
+}
+
+// EventLogForwarding enables logging each new cable modem event log entry.
+func (e *HubExporter) EventLogForwarding(enabled bool) *HubExporter {
+	e.eventLogForwarding = enabled
+	return e
+}
+
+// HealthThresholds sets the thresholds used to grade the signal quality of channels, instead of
+// health.DefaultThresholds.
+func (e *HubExporter) HealthThresholds(t health.Thresholds) *HubExporter {
+	e.healthThresholds = t
+	return e
+}
+
+// Password sets the admin password used to log in to the Hub, so password protected
+// endpoints can be queried.
+func (e *HubExporter) Password(password string) *HubExporter {
+	e.client.Password(password)
+	return e
+}
+
+// Close logs out of the Hub, if logged in.
+func (e *HubExporter) Close() error {
+	return e.client.Close()
+}
+
 // Describe sends the descriptors of each metric over the provided channel.
 func (e *HubExporter) Describe(ch chan<- *prometheus.Desc) {
 	ch <- e.descDownstreamPower
@@ -231,6 +622,11 @@ func (e *HubExporter) Describe(ch chan<- *prometheus.Desc) {
 	ch <- e.descDownstreamLockStatus
 	ch <- e.descDownstreamFrequencyHz
 
+	ch <- e.descDownstreamCorrectedRate
+	ch <- e.descDownstreamUncorrectedRate
+	ch <- e.descDownstreamCorrectedRatio
+	ch <- e.descDownstreamUncorrectedRatio
+
 	ch <- e.descUpstreamPower
 	ch <- e.descUpstreamSymbolRate
 	ch <- e.descUpstreamLockStatus
@@ -240,11 +636,38 @@ func (e *HubExporter) Describe(ch chan<- *prometheus.Desc) {
 	ch <- e.descUpstreamT3
 	ch <- e.descUpstreamT4
 
+	ch <- e.descUpstreamTimeoutEvents
+
 	ch <- e.descServiceMaxTrafficRate
 	ch <- e.descServiceMaxTrafficBurst
 	ch <- e.descServiceMinReservedRate
 	ch <- e.descServiceMaxConcatBurst
 
+	ch <- e.descDownstreamSummaryPowerMin
+	ch <- e.descDownstreamSummaryPowerMax
+	ch <- e.descDownstreamSummaryPowerAvg
+	ch <- e.descDownstreamSummaryPowerSpread
+	ch <- e.descDownstreamSummarySnrMin
+	ch <- e.descDownstreamSummarySnrMax
+	ch <- e.descDownstreamSummarySnrAvg
+	ch <- e.descDownstreamSummaryChannels
+	ch <- e.descDownstreamSummaryLocked
+	ch <- e.descDownstreamSummaryCorrected
+	ch <- e.descDownstreamSummaryUncorrected
+
+	ch <- e.descSpectrumTilt
+	ch <- e.descSpectrumSuckouts
+	ch <- e.descSpectrumMaxDip
+	ch <- e.descSpectrumRipple
+	ch <- e.descSpectrumRippleRms
+
+	ch <- e.descUpstreamSummaryPowerMin
+	ch <- e.descUpstreamSummaryPowerMax
+	ch <- e.descUpstreamSummaryPowerAvg
+	ch <- e.descUpstreamSummaryPowerSpread
+	ch <- e.descUpstreamSummaryChannels
+	ch <- e.descUpstreamSummaryLocked
+
 	ch <- e.descCableInfo
 	ch <- e.descCableUptimeSeconds
 	ch <- e.descCableStatus
@@ -252,20 +675,148 @@ func (e *HubExporter) Describe(ch chan<- *prometheus.Desc) {
 	ch <- e.descCableMaxCPEs
 	ch <- e.descCableBaselinePrivacy
 
+	ch <- e.descReboots
+	ch <- e.descLastRebootTimestamp
+	ch <- e.descLineupChanges
+	ch <- e.descChannelHealth
+
 	// describe per-endpoint up metrics
 	ch <- e.descDownstreamUp
 	ch <- e.descUpstreamUp
 	ch <- e.descServiceFlowsUp
 	ch <- e.descStateUp
+
+	ch <- e.descLastScrapeTimestamp
+
+	ch <- e.descLanHostsUp
+	ch <- e.descLanHosts
+	ch <- e.descLanHostInfo
+	ch <- e.descLanHostRssi
+	ch <- e.descLanHostSpeed
+
+	ch <- e.descProvisioningChanges
+	ch <- e.descTierInfo
+
+	ch <- e.descPlanRate
+	ch <- e.descPlanRateRatio
+	ch <- e.descPlanRateMismatch
+
+	ch <- e.descEventLogUp
+	ch <- e.descEventLogEvents
+
+	ch <- e.descWifiUp
+	ch <- e.descWifiRadioInfo
+	ch <- e.descWifiRadioEnabled
+	ch <- e.descWifiRadioChannel
+	ch <- e.descWifiRadioBandwidth
+	ch <- e.descWifiRadioTxPower
+	ch <- e.descWifiRadioSsids
+	ch <- e.descWifiRadioClients
+}
+
+// Scrape holds the data fetched from each of the Hub endpoints. Each field is nil if its
+// endpoint failed to be fetched, with the reason at the matching error field.
+type Scrape struct {
+	// Time when the scrape started
+	Time time.Time
+
+	Downstream    *hub6.Downstream
+	DownstreamErr error
+
+	Upstream    *hub6.Upstream
+	UpstreamErr error
+
+	ServiceFlows    *hub6.ServiceFlows
+	ServiceFlowsErr error
+
+	State    *hub6.State
+	StateErr error
+
+	// Not included in Err, as the endpoint is not known to be available on all firmware
+	// versions; reported by virginmedia_hub6_eventlog_up instead.
+	EventLog    *hub6.EventLog
+	EventLogErr error
+
+	// Only fetched when logging in to the Hub
+	Hosts    *hub6.Hosts
+	HostsErr error
+
+	WifiRadios    *hub6.WifiRadios
+	WifiRadiosErr error
+}
+
+// Scrape fetches the current state of all endpoints from the Hub.
+func (e *HubExporter) Scrape(ctx context.Context) *Scrape {
+	s := &Scrape{Time: time.Now()}
+	s.Downstream, s.DownstreamErr = e.fetchDownstream(ctx)
+	s.Upstream, s.UpstreamErr = e.fetchUpstream(ctx)
+	s.ServiceFlows, s.ServiceFlowsErr = e.fetchServiceFlows(ctx)
+	s.State, s.StateErr = e.fetchState(ctx)
+	s.EventLog, s.EventLogErr = e.fetchEventLog(ctx)
+	if e.client.HasPassword() {
+		s.Hosts, s.HostsErr = e.fetchHosts(ctx)
+		s.WifiRadios, s.WifiRadiosErr = e.fetchWifiRadios(ctx)
+	}
+	return s
+}
+
+// Err returns all endpoint errors joined, or nil if all endpoints were fetched successfully.
+// Event log errors are not included.
+func (s *Scrape) Err() error {
+	var errs []error
+	if s.DownstreamErr != nil {
+		errs = append(errs, fmt.Errorf("downstream: %w", s.DownstreamErr))
+	}
+	if s.UpstreamErr != nil {
+		errs = append(errs, fmt.Errorf("upstream: %w", s.UpstreamErr))
+	}
+	if s.ServiceFlowsErr != nil {
+		errs = append(errs, fmt.Errorf("serviceflows: %w", s.ServiceFlowsErr))
+	}
+	if s.StateErr != nil {
+		errs = append(errs, fmt.Errorf("state: %w", s.StateErr))
+	}
+	if s.HostsErr != nil {
+		errs = append(errs, fmt.Errorf("hosts: %w", s.HostsErr))
+	}
+	if s.WifiRadiosErr != nil {
+		errs = append(errs, fmt.Errorf("wifi radios: %w", s.WifiRadiosErr))
+	}
+	return errors.Join(errs...)
 }
 
 // Collect fetches the current state from the Hub and exports metrics.
 func (e *HubExporter) Collect(ch chan<- prometheus.Metric) {
-	ctx := context.Background()
+	e.collect(ch, e.Scrape(context.Background()))
+}
+
+// scrapeCollector exports metrics from a previously taken scrape.
+type scrapeCollector struct {
+	e *HubExporter
+	s *Scrape
+}
+
+func (c scrapeCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.e.Describe(ch)
+}
+
+func (c scrapeCollector) Collect(ch chan<- prometheus.Metric) {
+	c.e.collect(ch, c.s)
+}
+
+// ScrapeCollector returns a collector which exports metrics from s, instead of fetching them
+// from the Hub. State kept across scrapes (eg: reboot detection) is updated on every
+// collection, so it must be collected only once.
+func (e *HubExporter) ScrapeCollector(s *Scrape) prometheus.Collector {
+	return scrapeCollector{e: e, s: s}
+}
+
+func (e *HubExporter) collect(ch chan<- prometheus.Metric, s *Scrape) {
+	ch <- prometheus.MustNewConstMetric(e.descLastScrapeTimestamp, prometheus.GaugeValue, float64(s.Time.UnixNano())/1e9)
 
 	// Downstream
 	dsUp := 0.0
-	if ds, err := e.fetchDownstream(ctx); err == nil {
+	if ds := s.Downstream; ds != nil {
 		dsUp = 1.0
 		for _, c := range ds.DownstreamItem.DownstreamChannels {
 			labels := []string{strconv.FormatUint(c.ChannelId, 10), c.ChannelType, c.Modulation}
@@ -287,7 +838,7 @@ func (e *HubExporter) Collect(ch chan<- prometheus.Metric) {
 
 	// Upstream
 	usUp := 0.0
-	if us, err := e.fetchUpstream(ctx); err == nil {
+	if us := s.Upstream; us != nil {
 		usUp = 1.0
 		for _, c := range us.UpstreamItem.Channels {
 			labels := []string{strconv.FormatUint(c.ChannelId, 10), c.ChannelType, c.Modulation}
@@ -310,9 +861,9 @@ func (e *HubExporter) Collect(ch chan<- prometheus.Metric) {
 
 	// Service Flows
 	sfUp := 0.0
-	if sf, err := e.fetchServiceFlows(ctx); err == nil {
+	if sf := s.ServiceFlows; sf != nil {
 		sfUp = 1.0
-		for _, s := range sf.ServiceFlowItem.ServiceFlows {
+		for _, s := range sf.Flows() {
 			labels := []string{strconv.FormatUint(s.ServiceFlowId, 10), s.Direction, s.ScheduleType}
 			ch <- prometheus.MustNewConstMetric(e.descServiceMaxTrafficRate, prometheus.GaugeValue, float64(s.MaxTrafficRate), labels...)
 			ch <- prometheus.MustNewConstMetric(e.descServiceMaxTrafficBurst, prometheus.GaugeValue, float64(s.MaxTrafficBurst), labels...)
@@ -325,7 +876,7 @@ func (e *HubExporter) Collect(ch chan<- prometheus.Metric) {
 
 	// State
 	stUp := 0.0
-	if st, err := e.fetchState(ctx); err == nil {
+	if st := s.State; st != nil {
 		stUp = 1.0
 
 		// info metric (value 1) with identifying labels
@@ -366,52 +917,39 @@ func (e *HubExporter) Collect(ch chan<- prometheus.Metric) {
 			privacy = 1.0
 		}
 		ch <- prometheus.MustNewConstMetric(e.descCableBaselinePrivacy, prometheus.GaugeValue, privacy)
+
+		// reboots
+		bootTime, reboots, rebooted := e.rebootTracker.update(s.Time, st.CableModem.UpTime)
+		if rebooted {
+			slog.Warn("Hub reboot detected", "target", e.address, "boot_time", bootTime)
+			e.emit(s.Time, events.Reboot, events.Warning, "Hub rebooted",
+				events.Param{Name: "boot_time", Value: bootTime.UTC().Format(time.RFC3339)},
+			)
+		}
+		ch <- prometheus.MustNewConstMetric(e.descReboots, prometheus.CounterValue, float64(reboots))
+		ch <- prometheus.MustNewConstMetric(e.descLastRebootTimestamp, prometheus.GaugeValue, float64(bootTime.Unix()))
 	}
 	// emit state up metric
 	ch <- prometheus.MustNewConstMetric(e.descStateUp, prometheus.GaugeValue, stUp)
-}
 
-func (e *HubExporter) fetch(ctx context.Context, path string, out any) error {
-	url := fmt.Sprintf("http://%s%s", e.address, path)
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return err
-	}
-
-	resp, err := e.client.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, url)
-	}
-
-	// Read the full body so we can attempt a strict decode first, then fall back
-	// to a lenient unmarshal if necessary.
-	data, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read body from %s: %w", url, err)
-	}
-
-	// Attempt strict decoding (disallow unknown fields).
-	dec := json.NewDecoder(bytes.NewReader(data))
-	dec.DisallowUnknownFields()
-	if err := dec.Decode(out); err == nil {
-		return nil
-	}
-
-	// Fallback: lenient unmarshal (allows unknown fields).
-	if err := json.Unmarshal(data, out); err != nil {
-		return fmt.Errorf("failed to decode JSON from %s (strict then lenient): %w", url, err)
-	}
-	return nil
+	e.detectTransitions(s)
+	e.collectLineups(ch, s)
+	e.collectHealth(ch, s)
+	e.collectDownstreamSummary(ch, s)
+	e.collectUpstreamSummary(ch, s)
+	e.collectErrorRates(ch, s)
+	e.collectTimeoutEvents(ch, s)
+	e.collectSpectrum(ch, s)
+	e.collectProvisioning(ch, s)
+	e.collectPlan(ch, s)
+	e.collectEventLog(ch, s)
+	e.collectLanHosts(ch, s)
+	e.collectWifiRadios(ch, s)
 }
 
 func (e *HubExporter) fetchDownstream(ctx context.Context) (*hub6.Downstream, error) {
 	var ds hub6.Downstream
-	if err := e.fetch(ctx, "/rest/v1/cablemodem/downstream", &ds); err != nil {
+	if err := e.client.Get(ctx, "/rest/v1/cablemodem/downstream", &ds); err != nil {
 		return nil, err
 	}
 	return &ds, nil
@@ -419,7 +957,7 @@ func (e *HubExporter) fetchDownstream(ctx context.Context) (*hub6.Downstream, er
 
 func (e *HubExporter) fetchUpstream(ctx context.Context) (*hub6.Upstream, error) {
 	var us hub6.Upstream
-	if err := e.fetch(ctx, "/rest/v1/cablemodem/upstream", &us); err != nil {
+	if err := e.client.Get(ctx, "/rest/v1/cablemodem/upstream", &us); err != nil {
 		return nil, err
 	}
 	return &us, nil
@@ -427,7 +965,7 @@ func (e *HubExporter) fetchUpstream(ctx context.Context) (*hub6.Upstream, error)
 
 func (e *HubExporter) fetchServiceFlows(ctx context.Context) (*hub6.ServiceFlows, error) {
 	var sf hub6.ServiceFlows
-	if err := e.fetch(ctx, "/rest/v1/cablemodem/serviceflows", &sf); err != nil {
+	if err := e.client.Get(ctx, "/rest/v1/cablemodem/serviceflows", &sf); err != nil {
 		return nil, err
 	}
 	return &sf, nil
@@ -435,8 +973,32 @@ func (e *HubExporter) fetchServiceFlows(ctx context.Context) (*hub6.ServiceFlows
 
 func (e *HubExporter) fetchState(ctx context.Context) (*hub6.State, error) {
 	var st hub6.State
-	if err := e.fetch(ctx, "/rest/v1/cablemodem/state_", &st); err != nil {
+	if err := e.client.Get(ctx, "/rest/v1/cablemodem/state_", &st); err != nil {
 		return nil, err
 	}
 	return &st, nil
 }
+
+func (e *HubExporter) fetchEventLog(ctx context.Context) (*hub6.EventLog, error) {
+	var l hub6.EventLog
+	if err := e.client.Get(ctx, "/rest/v1/cablemodem/eventlog", &l); err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+func (e *HubExporter) fetchHosts(ctx context.Context) (*hub6.Hosts, error) {
+	var h hub6.Hosts
+	if err := e.client.Get(ctx, "/rest/v1/network/hosts?connectedOnly=true", &h); err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+func (e *HubExporter) fetchWifiRadios(ctx context.Context) (*hub6.WifiRadios, error) {
+	var w hub6.WifiRadios
+	if err := e.client.Get(ctx, "/rest/v1/wifi/radios", &w); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
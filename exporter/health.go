@@ -0,0 +1,27 @@
+package exporter
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// collectHealth exports the signal quality grade of each channel.
+func (e *HubExporter) collectHealth(ch chan<- prometheus.Metric, s *Scrape) {
+	if s.Downstream != nil {
+		for _, c := range s.Downstream.DownstreamItem.DownstreamChannels {
+			ch <- prometheus.MustNewConstMetric(
+				e.descChannelHealth, prometheus.GaugeValue, float64(e.healthThresholds.Downstream(c)),
+				"downstream", strconv.FormatUint(c.ChannelId, 10),
+			)
+		}
+	}
+	if s.Upstream != nil {
+		for _, c := range s.Upstream.UpstreamItem.Channels {
+			ch <- prometheus.MustNewConstMetric(
+				e.descChannelHealth, prometheus.GaugeValue, float64(e.healthThresholds.Upstream(c)),
+				"upstream", strconv.FormatUint(c.ChannelId, 10),
+			)
+		}
+	}
+}
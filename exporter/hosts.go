@@ -0,0 +1,45 @@
+package exporter
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// collectLanHosts exports the hosts connected to the Hub LAN, which are only fetched when logging
+// in to the Hub.
+func (e *HubExporter) collectLanHosts(ch chan<- prometheus.Metric, s *Scrape) {
+	if s.Hosts == nil {
+		if s.HostsErr != nil {
+			ch <- prometheus.MustNewConstMetric(e.descLanHostsUp, prometheus.GaugeValue, 0)
+		}
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(e.descLanHostsUp, prometheus.GaugeValue, 1)
+
+	counts := map[string]int{"ethernet": 0, "wifi": 0}
+	for _, h := range s.Hosts.HostsItem.Hosts {
+		if !h.Config.Connected {
+			continue
+		}
+		counts[h.Config.Interface]++
+
+		if !e.lanHostInfo {
+			continue
+		}
+		var band, ssid string
+		if h.Config.Wifi != nil {
+			band = h.Config.Wifi.Band
+			ssid = h.Config.Wifi.Ssid
+		}
+		ch <- prometheus.MustNewConstMetric(
+			e.descLanHostInfo, prometheus.GaugeValue, 1,
+			h.MacAddress, h.Config.Hostname, h.Config.Ipv4.Address, h.Config.Interface, band, ssid,
+		)
+		if h.Config.Wifi != nil {
+			ch <- prometheus.MustNewConstMetric(e.descLanHostRssi, prometheus.GaugeValue, float64(h.Config.Wifi.Rssi), h.MacAddress)
+		}
+		ch <- prometheus.MustNewConstMetric(e.descLanHostSpeed, prometheus.GaugeValue, float64(h.Config.Speed), h.MacAddress)
+	}
+	for iface, count := range counts {
+		ch <- prometheus.MustNewConstMetric(e.descLanHosts, prometheus.GaugeValue, float64(count), iface)
+	}
+}
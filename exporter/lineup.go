@@ -0,0 +1,166 @@
+package exporter
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/fornellas/virginmedia_hub6_exporter/events"
+)
+
+// lineupChanges holds the channels which changed between two lineups, as "id@frequency"
+// strings.
+type lineupChanges struct {
+	added            []string
+	removed          []string
+	frequencyChanged []string
+}
+
+func (c lineupChanges) empty() bool {
+	return len(c.added) == 0 && len(c.removed) == 0 && len(c.frequencyChanged) == 0
+}
+
+// lineupCounts holds the total number of channel changes seen so far.
+type lineupCounts struct {
+	added            uint64
+	removed          uint64
+	frequencyChanged uint64
+}
+
+// lineupTracker detects changes to the channel lineup (channel IDs and their frequencies) of a
+// single direction across scrapes.
+type lineupTracker struct {
+	mu sync.Mutex
+	// Frequency by channel ID; nil if not yet known
+	frequencies map[uint64]uint64
+	counts      lineupCounts
+}
+
+func formatChannel(id, frequency uint64) string {
+	return fmt.Sprintf("%d@%dHz", id, frequency)
+}
+
+// update records a new lineup, given as frequency by channel ID, and returns the changes from
+// the previous one, and the total counts.
+func (l *lineupTracker) update(frequencies map[uint64]uint64) (lineupChanges, lineupCounts) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var changes lineupChanges
+	if l.frequencies != nil {
+		ids := make([]uint64, 0, len(frequencies))
+		for id := range frequencies {
+			ids = append(ids, id)
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		for _, id := range ids {
+			previous, ok := l.frequencies[id]
+			if !ok {
+				changes.added = append(changes.added, formatChannel(id, frequencies[id]))
+			} else if previous != frequencies[id] {
+				changes.frequencyChanged = append(
+					changes.frequencyChanged,
+					fmt.Sprintf("%d@%dHz->%dHz", id, previous, frequencies[id]),
+				)
+			}
+		}
+
+		previousIds := make([]uint64, 0, len(l.frequencies))
+		for id := range l.frequencies {
+			previousIds = append(previousIds, id)
+		}
+		sort.Slice(previousIds, func(i, j int) bool { return previousIds[i] < previousIds[j] })
+		for _, id := range previousIds {
+			if _, ok := frequencies[id]; !ok {
+				changes.removed = append(changes.removed, formatChannel(id, l.frequencies[id]))
+			}
+		}
+
+		l.counts.added += uint64(len(changes.added))
+		l.counts.removed += uint64(len(changes.removed))
+		l.counts.frequencyChanged += uint64(len(changes.frequencyChanged))
+	}
+	l.frequencies = frequencies
+
+	return changes, l.counts
+}
+
+func (e *HubExporter) collectLineup(
+	ch chan<- prometheus.Metric, now time.Time, direction string, tracker *lineupTracker, frequencies map[uint64]uint64,
+) {
+	changes, counts := tracker.update(frequencies)
+	if !changes.empty() {
+		slog.Info("Channel lineup changed",
+			"target", e.address,
+			"direction", direction,
+			"added", changes.added,
+			"removed", changes.removed,
+			"frequency_changed", changes.frequencyChanged,
+		)
+		e.emit(now, events.LineupChanged, events.Notice,
+			fmt.Sprintf("Channel lineup changed on %s", direction),
+			events.Param{Name: "direction", Value: direction},
+			events.Param{Name: "added", Value: strings.Join(changes.added, ",")},
+			events.Param{Name: "removed", Value: strings.Join(changes.removed, ",")},
+			events.Param{Name: "frequency_changed", Value: strings.Join(changes.frequencyChanged, ",")},
+		)
+	}
+	ch <- prometheus.MustNewConstMetric(e.descLineupChanges, prometheus.CounterValue, float64(counts.added), direction, "added")
+	ch <- prometheus.MustNewConstMetric(e.descLineupChanges, prometheus.CounterValue, float64(counts.removed), direction, "removed")
+	ch <- prometheus.MustNewConstMetric(e.descLineupChanges, prometheus.CounterValue, float64(counts.frequencyChanged), direction, "frequency_changed")
+}
+
+// collectLineups exports channel lineup changes for each direction which was scraped
+// successfully.
+func (e *HubExporter) collectLineups(ch chan<- prometheus.Metric, s *Scrape) {
+	if s.Downstream != nil {
+		frequencies := map[uint64]uint64{}
+		for _, c := range s.Downstream.DownstreamItem.DownstreamChannels {
+			frequencies[c.ChannelId] = c.Frequency
+		}
+		e.collectLineup(ch, s.Time, "downstream", &e.downstreamLineupTracker, frequencies)
+	}
+	if s.Upstream != nil {
+		frequencies := map[uint64]uint64{}
+		for _, c := range s.Upstream.UpstreamItem.Channels {
+			frequencies[c.ChannelId] = c.Frequency
+		}
+		e.collectLineup(ch, s.Time, "upstream", &e.upstreamLineupTracker, frequencies)
+	}
+}
+
+// LineupState is the persistent state of channel lineup change detection.
+type LineupState struct {
+	// Frequency by channel ID; nil if not yet known
+	Frequencies      map[uint64]uint64 `json:"frequencies"`
+	Added            uint64            `json:"added"`
+	Removed          uint64            `json:"removed"`
+	FrequencyChanged uint64            `json:"frequencyChanged"`
+}
+
+func (l *lineupTracker) state() LineupState {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return LineupState{
+		Frequencies:      l.frequencies,
+		Added:            l.counts.added,
+		Removed:          l.counts.removed,
+		FrequencyChanged: l.counts.frequencyChanged,
+	}
+}
+
+func (l *lineupTracker) restore(s LineupState) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.frequencies = s.Frequencies
+	l.counts = lineupCounts{
+		added:            s.Added,
+		removed:          s.Removed,
+		frequencyChanged: s.FrequencyChanged,
+	}
+}
@@ -0,0 +1,69 @@
+package exporter
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLineupTrackerUpdate(t *testing.T) {
+	var tracker lineupTracker
+	for _, tc := range []struct {
+		name            string
+		frequencies     map[uint64]uint64
+		expectedChanges lineupChanges
+		expectedCounts  lineupCounts
+	}{
+		{
+			name:        "first scrape",
+			frequencies: map[uint64]uint64{1: 139000000, 2: 147000000},
+		},
+		{
+			name:        "unchanged",
+			frequencies: map[uint64]uint64{1: 139000000, 2: 147000000},
+		},
+		{
+			name:        "added, removed and frequency changed",
+			frequencies: map[uint64]uint64{1: 139000000, 2: 155000000, 4: 171000000, 3: 163000000},
+			expectedChanges: lineupChanges{
+				added:            []string{"3@163000000Hz", "4@171000000Hz"},
+				frequencyChanged: []string{"2@147000000Hz->155000000Hz"},
+			},
+			expectedCounts: lineupCounts{added: 2, frequencyChanged: 1},
+		},
+		{
+			name:        "removed",
+			frequencies: map[uint64]uint64{4: 171000000},
+			expectedChanges: lineupChanges{
+				removed: []string{"1@139000000Hz", "2@155000000Hz", "3@163000000Hz"},
+			},
+			expectedCounts: lineupCounts{added: 2, removed: 3, frequencyChanged: 1},
+		},
+	} {
+		changes, counts := tracker.update(tc.frequencies)
+		if !reflect.DeepEqual(changes, tc.expectedChanges) {
+			t.Errorf("%s: got changes %+v, expected %+v", tc.name, changes, tc.expectedChanges)
+		}
+		if counts != tc.expectedCounts {
+			t.Errorf("%s: got counts %+v, expected %+v", tc.name, counts, tc.expectedCounts)
+		}
+		if changes.empty() != reflect.DeepEqual(tc.expectedChanges, lineupChanges{}) {
+			t.Errorf("%s: unexpected empty %v", tc.name, changes.empty())
+		}
+	}
+}
+
+func TestLineupTrackerRestore(t *testing.T) {
+	var tracker lineupTracker
+	tracker.update(map[uint64]uint64{1: 139000000})
+	tracker.update(map[uint64]uint64{1: 139000000, 2: 147000000})
+
+	var restored lineupTracker
+	restored.restore(tracker.state())
+	changes, counts := restored.update(map[uint64]uint64{2: 147000000})
+	if !reflect.DeepEqual(changes, lineupChanges{removed: []string{"1@139000000Hz"}}) {
+		t.Errorf("got changes %+v", changes)
+	}
+	if counts != (lineupCounts{added: 1, removed: 1}) {
+		t.Errorf("got counts %+v", counts)
+	}
+}
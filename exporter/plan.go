@@ -0,0 +1,37 @@
+package exporter
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/fornellas/virginmedia_hub6_exporter/plan"
+)
+
+// Plan sets the speed tier paid for, so provisioned rates are checked against it.
+func (e *HubExporter) Plan(p plan.Plan) *HubExporter {
+	e.plan = &p
+	return e
+}
+
+// collectPlan exports the ratio between the primary service flow max traffic rate and the
+// declared plan rate of each direction.
+func (e *HubExporter) collectPlan(ch chan<- prometheus.Metric, s *Scrape) {
+	if e.plan == nil || s.ServiceFlows == nil {
+		return
+	}
+	rates := primaryRates(s.ServiceFlows)
+	for _, direction := range []string{"downstream", "upstream"} {
+		planRate := e.plan.Rate(direction)
+		rate, ok := rates[direction]
+		if planRate == 0 || !ok {
+			continue
+		}
+		ratio := float64(rate) / float64(planRate)
+		mismatch := 0.0
+		if ratio < 1 {
+			mismatch = 1.0
+		}
+		ch <- prometheus.MustNewConstMetric(e.descPlanRate, prometheus.GaugeValue, float64(planRate), direction)
+		ch <- prometheus.MustNewConstMetric(e.descPlanRateRatio, prometheus.GaugeValue, ratio, direction)
+		ch <- prometheus.MustNewConstMetric(e.descPlanRateMismatch, prometheus.GaugeValue, mismatch, direction)
+	}
+}
@@ -0,0 +1,174 @@
+package exporter
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/fornellas/virginmedia_hub6_exporter/events"
+	hub6 "github.com/fornellas/virginmedia_hub6_exporter/hub6"
+)
+
+// provisioningChange is a change of a provisioned value between scrapes.
+type provisioningChange struct {
+	// boot_filename, downstream_max_traffic_rate or upstream_max_traffic_rate
+	field string
+	old   string
+	new   string
+}
+
+// provisioningTracker detects provisioning changes, from the boot filename and service flow
+// max traffic rates, across scrapes.
+type provisioningTracker struct {
+	mu sync.Mutex
+	// Empty if not yet known
+	bootFilename string
+	// Max traffic rate by direction; nil if not yet known
+	rates map[string]uint64
+	// Total changes by field
+	counts map[string]uint64
+}
+
+func (t *provisioningTracker) record(change provisioningChange) {
+	if t.counts == nil {
+		t.counts = map[string]uint64{}
+	}
+	t.counts[change.field]++
+}
+
+// updateBootFilename records the current boot filename, and returns whether it changed.
+func (t *provisioningTracker) updateBootFilename(bootFilename string) (provisioningChange, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	change := provisioningChange{field: "boot_filename", old: t.bootFilename, new: bootFilename}
+	changed := t.bootFilename != "" && t.bootFilename != bootFilename
+	if changed {
+		t.record(change)
+	}
+	t.bootFilename = bootFilename
+	return change, changed
+}
+
+// updateRates records the current max traffic rate by direction, and returns the changed ones.
+// Directions missing from rates keep their last known rate.
+func (t *provisioningTracker) updateRates(rates map[string]uint64) []provisioningChange {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var changes []provisioningChange
+	for _, direction := range []string{"downstream", "upstream"} {
+		rate, ok := rates[direction]
+		if !ok {
+			continue
+		}
+		previous, ok := t.rates[direction]
+		if t.rates == nil {
+			t.rates = map[string]uint64{}
+		}
+		t.rates[direction] = rate
+		if !ok || previous == rate {
+			continue
+		}
+		change := provisioningChange{
+			field: direction + "_max_traffic_rate",
+			old:   strconv.FormatUint(previous, 10),
+			new:   strconv.FormatUint(rate, 10),
+		}
+		t.record(change)
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// totals returns the total changes by field.
+func (t *provisioningTracker) totals() map[string]uint64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	totals := map[string]uint64{
+		"boot_filename":               0,
+		"downstream_max_traffic_rate": 0,
+		"upstream_max_traffic_rate":   0,
+	}
+	for field, count := range t.counts {
+		totals[field] = count
+	}
+	return totals
+}
+
+func (e *HubExporter) reportProvisioningChange(now time.Time, change provisioningChange) {
+	slog.Warn("Provisioning changed", "target", e.address, "field", change.field, "old", change.old, "new", change.new)
+	e.emit(now, events.ProvisioningChanged, events.Notice,
+		fmt.Sprintf("Provisioning changed: %s from %s to %s", change.field, change.old, change.new),
+		events.Param{Name: "field", Value: change.field},
+		events.Param{Name: "old", Value: change.old},
+		events.Param{Name: "new", Value: change.new},
+	)
+}
+
+// primaryRates returns the max traffic rate of the primary service flow by direction. The
+// Hub does not identify the primary service flow, and lists service flows in no particular
+// order, so the one with the highest max traffic rate is taken as the primary, as other
+// service flows (eg: for voice) are expected to have lower rates.
+func primaryRates(serviceFlows *hub6.ServiceFlows) map[string]uint64 {
+	rates := map[string]uint64{}
+	for _, sf := range serviceFlows.Flows() {
+		if rate, ok := rates[sf.Direction]; !ok || sf.MaxTrafficRate > rate {
+			rates[sf.Direction] = sf.MaxTrafficRate
+		}
+	}
+	return rates
+}
+
+// collectProvisioning exports provisioning changes, and the tier parsed from the boot filename.
+func (e *HubExporter) collectProvisioning(ch chan<- prometheus.Metric, s *Scrape) {
+	if s.State != nil {
+		bootFilename := s.State.CableModem.BootFilename
+		if change, changed := e.provisioningTracker.updateBootFilename(bootFilename); changed {
+			e.reportProvisioningChange(s.Time, change)
+		}
+		bootFile := hub6.ParseBootFilename(bootFilename)
+		ch <- prometheus.MustNewConstMetric(
+			e.descTierInfo, prometheus.GaugeValue, 1,
+			bootFilename, bootFile.Model, bootFile.Segment, bootFile.Tier, bootFile.Wifi, bootFile.IpMode,
+		)
+	}
+	if s.ServiceFlows != nil {
+		for _, change := range e.provisioningTracker.updateRates(primaryRates(s.ServiceFlows)) {
+			e.reportProvisioningChange(s.Time, change)
+		}
+	}
+	for field, count := range e.provisioningTracker.totals() {
+		ch <- prometheus.MustNewConstMetric(e.descProvisioningChanges, prometheus.CounterValue, float64(count), field)
+	}
+}
+
+// ProvisioningState is the persistent state of provisioning change detection.
+type ProvisioningState struct {
+	BootFilename string `json:"bootFilename"`
+	// Max traffic rate by direction; nil if not yet known
+	Rates map[string]uint64 `json:"rates"`
+	// Total changes by field
+	Counts map[string]uint64 `json:"counts"`
+}
+
+func (t *provisioningTracker) state() ProvisioningState {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return ProvisioningState{
+		BootFilename: t.bootFilename,
+		Rates:        maps.Clone(t.rates),
+		Counts:       maps.Clone(t.counts),
+	}
+}
+
+func (t *provisioningTracker) restore(s ProvisioningState) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.bootFilename = s.BootFilename
+	t.rates = s.Rates
+	t.counts = s.Counts
+}
@@ -0,0 +1,57 @@
+package exporter
+
+import (
+	"reflect"
+	"testing"
+
+	hub6 "github.com/fornellas/virginmedia_hub6_exporter/hub6"
+)
+
+func TestProvisioningTrackerUpdateRates(t *testing.T) {
+	var tracker provisioningTracker
+
+	// Rates are unknown on the first scrape
+	if changes := tracker.updateRates(map[string]uint64{"downstream": 1000, "upstream": 100}); len(changes) != 0 {
+		t.Fatalf("first scrape: got %v, expected no changes", changes)
+	}
+
+	// A direction missing from a scrape keeps its last known rate
+	if changes := tracker.updateRates(map[string]uint64{"downstream": 1000}); len(changes) != 0 {
+		t.Fatalf("got %v, expected no changes", changes)
+	}
+	expected := []provisioningChange{{field: "upstream_max_traffic_rate", old: "100", new: "200"}}
+	if changes := tracker.updateRates(map[string]uint64{"downstream": 1000, "upstream": 200}); !reflect.DeepEqual(changes, expected) {
+		t.Errorf("got %v, expected %v", changes, expected)
+	}
+
+	expected = []provisioningChange{{field: "downstream_max_traffic_rate", old: "1000", new: "2000"}}
+	if changes := tracker.updateRates(map[string]uint64{"downstream": 2000}); !reflect.DeepEqual(changes, expected) {
+		t.Errorf("got %v, expected %v", changes, expected)
+	}
+
+	expectedTotals := map[string]uint64{
+		"boot_filename":               0,
+		"downstream_max_traffic_rate": 1,
+		"upstream_max_traffic_rate":   1,
+	}
+	if totals := tracker.totals(); !reflect.DeepEqual(totals, expectedTotals) {
+		t.Errorf("got %v, expected %v", totals, expectedTotals)
+	}
+}
+
+func TestPrimaryRates(t *testing.T) {
+	serviceFlows := &hub6.ServiceFlows{ServiceFlowItems: []hub6.ServiceFlowItem{
+		{ServiceFlow: hub6.ServiceFlow{ServiceFlowId: 1, Direction: "downstream", MaxTrafficRate: 128000}},
+		{ServiceFlow: hub6.ServiceFlow{ServiceFlowId: 2, Direction: "upstream", MaxTrafficRate: 55000000}},
+		{ServiceFlow: hub6.ServiceFlow{ServiceFlowId: 3, Direction: "downstream", MaxTrafficRate: 1100000000}},
+		{ServiceFlow: hub6.ServiceFlow{ServiceFlowId: 4, Direction: "upstream", MaxTrafficRate: 128000}},
+	}}
+	expected := map[string]uint64{"downstream": 1100000000, "upstream": 55000000}
+	if rates := primaryRates(serviceFlows); !reflect.DeepEqual(rates, expected) {
+		t.Errorf("got %v, expected %v", rates, expected)
+	}
+
+	if rates := primaryRates(&hub6.ServiceFlows{}); len(rates) != 0 {
+		t.Errorf("got %v, expected no rates", rates)
+	}
+}
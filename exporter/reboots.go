@@ -0,0 +1,56 @@
+package exporter
+
+import (
+	"sync"
+	"time"
+)
+
+// Uptime is reported in seconds, and the clocks of the exporter and the Hub may drift, so boot
+// times derived from it are only considered different beyond this tolerance.
+const bootTimeTolerance = 60 * time.Second
+
+// rebootTracker detects Hub reboots by comparing its boot time (scrape time minus uptime)
+// across scrapes. This works even if scrapes are further apart than the reboot.
+type rebootTracker struct {
+	mu sync.Mutex
+	// Zero if not yet known
+	bootTime time.Time
+	reboots  uint64
+}
+
+// update records a new uptime sample taken at now, and returns the current boot time, total
+// number of reboots and whether a reboot was detected.
+func (r *rebootTracker) update(now time.Time, uptime uint64) (time.Time, uint64, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	bootTime := now.Add(-time.Duration(uptime) * time.Second)
+
+	rebooted := false
+	if !r.bootTime.IsZero() && bootTime.Sub(r.bootTime) > bootTimeTolerance {
+		rebooted = true
+		r.reboots++
+	}
+	r.bootTime = bootTime
+
+	return bootTime, r.reboots, rebooted
+}
+
+// RebootState is the persistent state of reboot detection.
+type RebootState struct {
+	BootTime time.Time `json:"bootTime"`
+	Reboots  uint64    `json:"reboots"`
+}
+
+func (r *rebootTracker) state() RebootState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return RebootState{BootTime: r.bootTime, Reboots: r.reboots}
+}
+
+func (r *rebootTracker) restore(s RebootState) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.bootTime = s.BootTime
+	r.reboots = s.Reboots
+}
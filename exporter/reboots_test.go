@@ -0,0 +1,50 @@
+package exporter
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRebootTrackerUpdate(t *testing.T) {
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	bootTime := start.Add(-1000 * time.Second)
+
+	var tracker rebootTracker
+	for _, tc := range []struct {
+		name             string
+		now              time.Time
+		uptime           uint64
+		expectedBootTime time.Time
+		expectedReboots  uint64
+		expectedRebooted bool
+	}{
+		{"first scrape", start, 1000, bootTime, 0, false},
+		{"same boot", start.Add(time.Minute), 1060, bootTime, 0, false},
+		// eg: the clocks of the exporter and the Hub drift
+		{"within tolerance", start.Add(2 * time.Minute), 1100, bootTime.Add(20 * time.Second), 0, false},
+		{"reboot", start.Add(3 * time.Minute), 30, start.Add(3*time.Minute - 30*time.Second), 1, true},
+		// Scrapes further apart than the uptime since the reboot
+		{"reboot between distant scrapes", start.Add(time.Hour), 600, start.Add(50 * time.Minute), 2, true},
+		{"after the reboot", start.Add(time.Hour + time.Minute), 660, start.Add(50 * time.Minute), 2, false},
+	} {
+		bootTime, reboots, rebooted := tracker.update(tc.now, tc.uptime)
+		if !bootTime.Equal(tc.expectedBootTime) || reboots != tc.expectedReboots || rebooted != tc.expectedRebooted {
+			t.Errorf("%s: got %v %d %v, expected %v %d %v",
+				tc.name, bootTime, reboots, rebooted, tc.expectedBootTime, tc.expectedReboots, tc.expectedRebooted)
+		}
+	}
+}
+
+func TestRebootTrackerRestore(t *testing.T) {
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	var tracker rebootTracker
+	tracker.update(start, 1000)
+	tracker.update(start.Add(time.Minute), 10)
+
+	// A reboot while the exporter was down is detected after a restart
+	var restored rebootTracker
+	restored.restore(tracker.state())
+	if _, reboots, rebooted := restored.update(start.Add(time.Hour), 60); reboots != 2 || !rebooted {
+		t.Errorf("got %d %v, expected 2 reboots and a reboot", reboots, rebooted)
+	}
+}
@@ -0,0 +1,23 @@
+package exporter
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/fornellas/virginmedia_hub6_exporter/spectrum"
+)
+
+// collectSpectrum exports the analysis of the downstream frequency response.
+func (e *HubExporter) collectSpectrum(ch chan<- prometheus.Metric, s *Scrape) {
+	if s.Downstream == nil {
+		return
+	}
+	a, ok := spectrum.Analyze(s.Downstream.DownstreamItem.DownstreamChannels)
+	if !ok {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(e.descSpectrumTilt, prometheus.GaugeValue, a.TiltDbPer100Mhz)
+	ch <- prometheus.MustNewConstMetric(e.descSpectrumSuckouts, prometheus.GaugeValue, float64(a.Suckouts))
+	ch <- prometheus.MustNewConstMetric(e.descSpectrumMaxDip, prometheus.GaugeValue, a.MaxDipDb)
+	ch <- prometheus.MustNewConstMetric(e.descSpectrumRipple, prometheus.GaugeValue, a.RippleDb)
+	ch <- prometheus.MustNewConstMetric(e.descSpectrumRippleRms, prometheus.GaugeValue, a.RippleRmsDb)
+}
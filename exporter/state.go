@@ -0,0 +1,40 @@
+package exporter
+
+// State is the persistent state a HubExporter keeps across scrapes, so it can be saved and
+// restored across exporter restarts.
+type State struct {
+	Reboots          RebootState       `json:"reboots"`
+	DownstreamLineup LineupState       `json:"downstreamLineup"`
+	UpstreamLineup   LineupState       `json:"upstreamLineup"`
+	ErrorRates       ErrorRateState    `json:"errorRates"`
+	Timeouts         TimeoutState      `json:"timeouts"`
+	EventLog         EventLogState     `json:"eventLog"`
+	Transitions      TransitionState   `json:"transitions"`
+	Provisioning     ProvisioningState `json:"provisioning"`
+}
+
+// State returns a snapshot of the current state.
+func (e *HubExporter) State() *State {
+	return &State{
+		Reboots:          e.rebootTracker.state(),
+		DownstreamLineup: e.downstreamLineupTracker.state(),
+		UpstreamLineup:   e.upstreamLineupTracker.state(),
+		ErrorRates:       e.errorRateTracker.state(),
+		Timeouts:         e.timeoutTracker.state(),
+		EventLog:         e.eventLogTracker.state(),
+		Transitions:      e.transitionTracker.state(),
+		Provisioning:     e.provisioningTracker.state(),
+	}
+}
+
+// RestoreState replaces the current state with s, previously returned by State.
+func (e *HubExporter) RestoreState(s *State) {
+	e.rebootTracker.restore(s.Reboots)
+	e.downstreamLineupTracker.restore(s.DownstreamLineup)
+	e.upstreamLineupTracker.restore(s.UpstreamLineup)
+	e.errorRateTracker.restore(s.ErrorRates)
+	e.timeoutTracker.restore(s.Timeouts)
+	e.eventLogTracker.restore(s.EventLog)
+	e.transitionTracker.restore(s.Transitions)
+	e.provisioningTracker.restore(s.Provisioning)
+}
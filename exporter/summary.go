@@ -0,0 +1,110 @@
+package exporter
+
+import (
+	"math"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// stats accumulates the minimum, maximum and average of values.
+type stats struct {
+	min   float64
+	max   float64
+	sum   float64
+	count int
+}
+
+func (s *stats) add(v float64) {
+	if s.count == 0 {
+		s.min = math.Inf(1)
+		s.max = math.Inf(-1)
+	}
+	s.min = math.Min(s.min, v)
+	s.max = math.Max(s.max, v)
+	s.sum += v
+	s.count++
+}
+
+func (s *stats) avg() float64 {
+	return s.sum / float64(s.count)
+}
+
+// channelCounts counts total and locked channels by channel type.
+type channelCounts struct {
+	total  map[string]uint64
+	locked map[string]uint64
+}
+
+func newChannelCounts() channelCounts {
+	return channelCounts{
+		total:  map[string]uint64{},
+		locked: map[string]uint64{},
+	}
+}
+
+func (c channelCounts) add(channelType string, locked bool) {
+	c.total[channelType]++
+	if locked {
+		c.locked[channelType]++
+	}
+}
+
+func (c channelCounts) collect(ch chan<- prometheus.Metric, descTotal, descLocked *prometheus.Desc) {
+	for channelType, total := range c.total {
+		ch <- prometheus.MustNewConstMetric(descTotal, prometheus.GaugeValue, float64(total), channelType)
+		ch <- prometheus.MustNewConstMetric(descLocked, prometheus.GaugeValue, float64(c.locked[channelType]), channelType)
+	}
+}
+
+func (e *HubExporter) collectDownstreamSummary(ch chan<- prometheus.Metric, s *Scrape) {
+	if s.Downstream == nil {
+		return
+	}
+
+	var power, snr stats
+	counts := newChannelCounts()
+	var corrected, uncorrected uint64
+	for _, c := range s.Downstream.DownstreamItem.DownstreamChannels {
+		power.add(c.PowerDbmv())
+		snr.add(c.MerDb())
+		counts.add(c.ChannelType, c.LockStatus)
+		corrected += c.CorrectedErrors
+		uncorrected += c.UncorrectedErrors
+	}
+
+	counts.collect(ch, e.descDownstreamSummaryChannels, e.descDownstreamSummaryLocked)
+	ch <- prometheus.MustNewConstMetric(e.descDownstreamSummaryCorrected, prometheus.GaugeValue, float64(corrected))
+	ch <- prometheus.MustNewConstMetric(e.descDownstreamSummaryUncorrected, prometheus.GaugeValue, float64(uncorrected))
+	if power.count == 0 {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(e.descDownstreamSummaryPowerMin, prometheus.GaugeValue, power.min)
+	ch <- prometheus.MustNewConstMetric(e.descDownstreamSummaryPowerMax, prometheus.GaugeValue, power.max)
+	ch <- prometheus.MustNewConstMetric(e.descDownstreamSummaryPowerAvg, prometheus.GaugeValue, power.avg())
+	ch <- prometheus.MustNewConstMetric(e.descDownstreamSummaryPowerSpread, prometheus.GaugeValue, power.max-power.min)
+	ch <- prometheus.MustNewConstMetric(e.descDownstreamSummarySnrMin, prometheus.GaugeValue, snr.min)
+	ch <- prometheus.MustNewConstMetric(e.descDownstreamSummarySnrMax, prometheus.GaugeValue, snr.max)
+	ch <- prometheus.MustNewConstMetric(e.descDownstreamSummarySnrAvg, prometheus.GaugeValue, snr.avg())
+}
+
+func (e *HubExporter) collectUpstreamSummary(ch chan<- prometheus.Metric, s *Scrape) {
+	if s.Upstream == nil {
+		return
+	}
+
+	var power stats
+	counts := newChannelCounts()
+	for _, c := range s.Upstream.UpstreamItem.Channels {
+		power.add(c.Power)
+		counts.add(c.ChannelType, c.LockStatus)
+	}
+
+	counts.collect(ch, e.descUpstreamSummaryChannels, e.descUpstreamSummaryLocked)
+	if power.count == 0 {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(e.descUpstreamSummaryPowerMin, prometheus.GaugeValue, power.min)
+	ch <- prometheus.MustNewConstMetric(e.descUpstreamSummaryPowerMax, prometheus.GaugeValue, power.max)
+	ch <- prometheus.MustNewConstMetric(e.descUpstreamSummaryPowerAvg, prometheus.GaugeValue, power.avg())
+	ch <- prometheus.MustNewConstMetric(e.descUpstreamSummaryPowerSpread, prometheus.GaugeValue, power.max-power.min)
+}
@@ -0,0 +1,136 @@
+package exporter
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	hub6 "github.com/fornellas/virginmedia_hub6_exporter/hub6"
+)
+
+// gather collects metrics from s, returning the value of each metric by name, followed by its
+// label values in braces, if any (eg: name{ofdm}).
+func gather(t *testing.T, e *HubExporter, s *Scrape) map[string]float64 {
+	t.Helper()
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(e.ScrapeCollector(s))
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := map[string]float64{}
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			name := family.GetName()
+			if labels := m.GetLabel(); len(labels) > 0 {
+				var labelValues []string
+				for _, l := range labels {
+					labelValues = append(labelValues, l.GetValue())
+				}
+				name += "{" + strings.Join(labelValues, ",") + "}"
+			}
+			switch family.GetType() {
+			case dto.MetricType_COUNTER:
+				values[name] = m.GetCounter().GetValue()
+			default:
+				values[name] = m.GetGauge().GetValue()
+			}
+		}
+	}
+	return values
+}
+
+func TestStats(t *testing.T) {
+	var s stats
+	for _, v := range []float64{3, -1.5, 7} {
+		s.add(v)
+	}
+	if s.min != -1.5 || s.max != 7 || s.avg() != 8.5/3 {
+		t.Errorf("got min %v max %v avg %v, expected -1.5 7 %v", s.min, s.max, s.avg(), 8.5/3)
+	}
+
+	// The first value sets both the minimum and maximum, even if it is beyond the zero value
+	var negative stats
+	negative.add(-4)
+	if negative.min != -4 || negative.max != -4 || negative.avg() != -4 {
+		t.Errorf("got min %v max %v avg %v, expected -4", negative.min, negative.max, negative.avg())
+	}
+}
+
+func TestCollectSummary(t *testing.T) {
+	e := NewHubExporter("192.168.0.1", time.Second)
+	s := &Scrape{
+		Time: time.Unix(1700000000, 0),
+		Downstream: &hub6.Downstream{DownstreamItem: hub6.DownstreamItem{DownstreamChannels: []hub6.DownstreamChannel{
+			{ChannelType: "sc_qam", ChannelId: 1, Power: 4, Snr: 40, LockStatus: true, CorrectedErrors: 10, UncorrectedErrors: 1},
+			{ChannelType: "sc_qam", ChannelId: 2, Power: -2, Snr: 36, LockStatus: false, CorrectedErrors: 5, UncorrectedErrors: 2},
+			// The Hub reports OFDM power and RxMER in tenths
+			{ChannelType: "ofdm", ChannelId: 33, Power: 52, RxMer: 380, LockStatus: true, CorrectedErrors: 100},
+		}}},
+		Upstream: &hub6.Upstream{UpstreamItem: hub6.UpstreamItem{Channels: []hub6.UpstreamChannel{
+			{ChannelType: "atdma", ChannelId: 1, Power: 44.5, LockStatus: true},
+			{ChannelType: "atdma", ChannelId: 2, Power: 47, LockStatus: true},
+			{ChannelType: "atdma", ChannelId: 3, Power: 42, LockStatus: false},
+		}}},
+	}
+	values := gather(t, e, s)
+
+	for name, expected := range map[string]float64{
+		"virginmedia_hub6_downstream_summary_power_min_dbmv":          -2,
+		"virginmedia_hub6_downstream_summary_power_max_dbmv":          5.2,
+		"virginmedia_hub6_downstream_summary_power_avg_dbmv":          (4 - 2 + 5.2) / 3,
+		"virginmedia_hub6_downstream_summary_power_spread_db":         7.2,
+		"virginmedia_hub6_downstream_summary_snr_min_db":              36,
+		"virginmedia_hub6_downstream_summary_snr_max_db":              40,
+		"virginmedia_hub6_downstream_summary_snr_avg_db":              (40 + 36 + 38) / 3.0,
+		"virginmedia_hub6_downstream_summary_channels{sc_qam}":        2,
+		"virginmedia_hub6_downstream_summary_locked_channels{sc_qam}": 1,
+		"virginmedia_hub6_downstream_summary_channels{ofdm}":          1,
+		"virginmedia_hub6_downstream_summary_locked_channels{ofdm}":   1,
+		"virginmedia_hub6_downstream_summary_corrected_errors":        115,
+		"virginmedia_hub6_downstream_summary_uncorrected_errors":      3,
+		"virginmedia_hub6_upstream_summary_power_min_dbmv":            42,
+		"virginmedia_hub6_upstream_summary_power_max_dbmv":            47,
+		"virginmedia_hub6_upstream_summary_power_avg_dbmv":            44.5,
+		"virginmedia_hub6_upstream_summary_power_spread_db":           5,
+		"virginmedia_hub6_upstream_summary_channels{atdma}":           3,
+		"virginmedia_hub6_upstream_summary_locked_channels{atdma}":    2,
+	} {
+		value, ok := values[name]
+		if !ok {
+			t.Errorf("%s: missing", name)
+			continue
+		}
+		if math.Abs(value-expected) > 1e-9 {
+			t.Errorf("%s: got %v, expected %v", name, value, expected)
+		}
+	}
+}
+
+func TestCollectSummaryNoChannels(t *testing.T) {
+	e := NewHubExporter("192.168.0.1", time.Second)
+	s := &Scrape{
+		Time:       time.Unix(1700000000, 0),
+		Downstream: &hub6.Downstream{},
+		Upstream:   &hub6.Upstream{},
+	}
+	values := gather(t, e, s)
+
+	// Totals are known to be zero, but there are no values to summarize
+	if value, ok := values["virginmedia_hub6_downstream_summary_corrected_errors"]; !ok || value != 0 {
+		t.Errorf("got corrected errors %v (%v), expected 0", value, ok)
+	}
+	for _, name := range []string{
+		"virginmedia_hub6_downstream_summary_power_min_dbmv",
+		"virginmedia_hub6_downstream_summary_snr_avg_db",
+		"virginmedia_hub6_upstream_summary_power_avg_dbmv",
+	} {
+		if value, ok := values[name]; ok {
+			t.Errorf("%s: got %v, expected no metric", name, value)
+		}
+	}
+}
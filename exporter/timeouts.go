@@ -0,0 +1,150 @@
+package exporter
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	hub6 "github.com/fornellas/virginmedia_hub6_exporter/hub6"
+)
+
+// timeoutCounts holds upstream T3 / T4 timeout counts.
+type timeoutCounts struct {
+	t3 uint64
+	t4 uint64
+}
+
+// timeoutEvent is an increment of a timeout counter of an upstream channel between scrapes.
+type timeoutEvent struct {
+	channelId uint64
+	// t3 or t4
+	timeoutType string
+	count       uint64
+	// The event happened between these times
+	since time.Time
+	until time.Time
+}
+
+// timeoutTracker detects increments of upstream T3 / T4 timeout counters between scrapes.
+type timeoutTracker struct {
+	mu sync.Mutex
+	// Zero if no previous scrape
+	lastTime time.Time
+	// Previous counts by channel ID
+	counts map[uint64]timeoutCounts
+	// Total events detected
+	totals timeoutCounts
+}
+
+// update records the timeout counts of all current channels, sampled at now, and returns
+// detected events along with the total events so far.
+func (t *timeoutTracker) update(now time.Time, channels []hub6.UpstreamChannel) ([]timeoutEvent, timeoutCounts) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var events []timeoutEvent
+	counts := map[uint64]timeoutCounts{}
+	for _, c := range channels {
+		current := timeoutCounts{t3: c.T3Timeout, t4: c.T4Timeout}
+		counts[c.ChannelId] = current
+		previous, ok := t.counts[c.ChannelId]
+		if !ok {
+			continue
+		}
+		// Counters reset when the modem reboots, so any count after a reset is new events.
+		for _, e := range []struct {
+			timeoutType       string
+			previous, current uint64
+		}{
+			{"t3", previous.t3, current.t3},
+			{"t4", previous.t4, current.t4},
+		} {
+			increment := e.current
+			if e.current >= e.previous {
+				increment = e.current - e.previous
+			}
+			if increment == 0 {
+				continue
+			}
+			events = append(events, timeoutEvent{
+				channelId:   c.ChannelId,
+				timeoutType: e.timeoutType,
+				count:       increment,
+				since:       t.lastTime,
+				until:       now,
+			})
+			switch e.timeoutType {
+			case "t3":
+				t.totals.t3 += increment
+			case "t4":
+				t.totals.t4 += increment
+			}
+		}
+	}
+	t.counts = counts
+	t.lastTime = now
+
+	return events, t.totals
+}
+
+// collectTimeoutEvents exports the number of upstream timeout events detected between scrapes
+// and logs each of them.
+func (e *HubExporter) collectTimeoutEvents(ch chan<- prometheus.Metric, s *Scrape) {
+	if s.Upstream == nil {
+		return
+	}
+	events, totals := e.timeoutTracker.update(s.Time, s.Upstream.UpstreamItem.Channels)
+	for _, event := range events {
+		slog.Warn("Upstream timeout",
+			"target", e.address,
+			"type", event.timeoutType,
+			"channel_id", event.channelId,
+			"count", event.count,
+			"since", event.since,
+			"until", event.until,
+		)
+	}
+	ch <- prometheus.MustNewConstMetric(e.descUpstreamTimeoutEvents, prometheus.CounterValue, float64(totals.t3), "t3")
+	ch <- prometheus.MustNewConstMetric(e.descUpstreamTimeoutEvents, prometheus.CounterValue, float64(totals.t4), "t4")
+}
+
+// TimeoutCountsState holds upstream T3 / T4 timeout counts.
+type TimeoutCountsState struct {
+	T3 uint64 `json:"t3"`
+	T4 uint64 `json:"t4"`
+}
+
+// TimeoutState is the persistent state of upstream timeout event detection.
+type TimeoutState struct {
+	LastTime time.Time `json:"lastTime"`
+	// Previous counts by channel ID
+	Counts map[uint64]TimeoutCountsState `json:"counts"`
+	Totals TimeoutCountsState            `json:"totals"`
+}
+
+func (t *timeoutTracker) state() TimeoutState {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s := TimeoutState{
+		LastTime: t.lastTime,
+		Counts:   map[uint64]TimeoutCountsState{},
+		Totals:   TimeoutCountsState{T3: t.totals.t3, T4: t.totals.t4},
+	}
+	for id, c := range t.counts {
+		s.Counts[id] = TimeoutCountsState{T3: c.t3, T4: c.t4}
+	}
+	return s
+}
+
+func (t *timeoutTracker) restore(s TimeoutState) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.lastTime = s.LastTime
+	t.counts = map[uint64]timeoutCounts{}
+	for id, c := range s.Counts {
+		t.counts[id] = timeoutCounts{t3: c.T3, t4: c.T4}
+	}
+	t.totals = timeoutCounts{t3: s.Totals.T3, t4: s.Totals.T4}
+}
@@ -0,0 +1,92 @@
+package exporter
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	hub6 "github.com/fornellas/virginmedia_hub6_exporter/hub6"
+)
+
+func TestTimeoutTrackerUpdate(t *testing.T) {
+	var tracker timeoutTracker
+	start := time.Unix(1700000000, 0)
+	for i, tc := range []struct {
+		name           string
+		channels       []hub6.UpstreamChannel
+		expectedEvents []timeoutEvent
+		expectedTotals timeoutCounts
+	}{
+		{
+			name: "first scrape",
+			channels: []hub6.UpstreamChannel{
+				{ChannelId: 1, T3Timeout: 5, T4Timeout: 1},
+				{ChannelId: 2, T3Timeout: 2},
+			},
+		},
+		{
+			name: "unchanged",
+			channels: []hub6.UpstreamChannel{
+				{ChannelId: 1, T3Timeout: 5, T4Timeout: 1},
+				{ChannelId: 2, T3Timeout: 2},
+			},
+		},
+		{
+			name: "incremented, with a new channel",
+			channels: []hub6.UpstreamChannel{
+				{ChannelId: 1, T3Timeout: 8, T4Timeout: 2},
+				{ChannelId: 2, T3Timeout: 2},
+				{ChannelId: 3, T3Timeout: 4},
+			},
+			expectedEvents: []timeoutEvent{
+				{channelId: 1, timeoutType: "t3", count: 3, since: start.Add(10 * time.Second), until: start.Add(20 * time.Second)},
+				{channelId: 1, timeoutType: "t4", count: 1, since: start.Add(10 * time.Second), until: start.Add(20 * time.Second)},
+			},
+			expectedTotals: timeoutCounts{t3: 3, t4: 1},
+		},
+		{
+			name: "reset",
+			channels: []hub6.UpstreamChannel{
+				{ChannelId: 1, T3Timeout: 1},
+				{ChannelId: 2, T3Timeout: 2},
+				{ChannelId: 3, T3Timeout: 4},
+			},
+			expectedEvents: []timeoutEvent{
+				{channelId: 1, timeoutType: "t3", count: 1, since: start.Add(20 * time.Second), until: start.Add(30 * time.Second)},
+			},
+			expectedTotals: timeoutCounts{t3: 4, t4: 1},
+		},
+	} {
+		events, totals := tracker.update(start.Add(time.Duration(i)*10*time.Second), tc.channels)
+		if !reflect.DeepEqual(events, tc.expectedEvents) {
+			t.Errorf("%s: got events %+v, expected %+v", tc.name, events, tc.expectedEvents)
+		}
+		if totals != tc.expectedTotals {
+			t.Errorf("%s: got totals %+v, expected %+v", tc.name, totals, tc.expectedTotals)
+		}
+	}
+}
+
+func TestTimeoutTrackerRestore(t *testing.T) {
+	var tracker timeoutTracker
+	start := time.Unix(1700000000, 0)
+	tracker.update(start, []hub6.UpstreamChannel{{ChannelId: 1, T3Timeout: 5}})
+	tracker.update(start.Add(10*time.Second), []hub6.UpstreamChannel{{ChannelId: 1, T3Timeout: 7}})
+
+	var restored timeoutTracker
+	restored.restore(tracker.state())
+	if !reflect.DeepEqual(restored.state(), tracker.state()) {
+		t.Fatalf("got state %+v, expected %+v", restored.state(), tracker.state())
+	}
+
+	events, totals := restored.update(start.Add(20*time.Second), []hub6.UpstreamChannel{{ChannelId: 1, T3Timeout: 8}})
+	expectedEvents := []timeoutEvent{
+		{channelId: 1, timeoutType: "t3", count: 1, since: start.Add(10 * time.Second), until: start.Add(20 * time.Second)},
+	}
+	if !reflect.DeepEqual(events, expectedEvents) {
+		t.Errorf("got events %+v, expected %+v", events, expectedEvents)
+	}
+	if expected := (timeoutCounts{t3: 3}); totals != expected {
+		t.Errorf("got totals %+v, expected %+v", totals, expected)
+	}
+}
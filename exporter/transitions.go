@@ -0,0 +1,146 @@
+package exporter
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/fornellas/virginmedia_hub6_exporter/events"
+)
+
+// transitionTracker detects changes of the cable modem status, network access and channel lock
+// status across scrapes.
+type transitionTracker struct {
+	mu sync.Mutex
+	// Empty if not yet known
+	status string
+	// nil if not yet known
+	accessAllowed *bool
+	// Lock status by channel ID; nil if not yet known
+	downstreamLocks map[uint64]bool
+	upstreamLocks   map[uint64]bool
+}
+
+func (t *transitionTracker) updateState(now time.Time, e *HubExporter, status string, accessAllowed bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.status != "" && t.status != status {
+		slog.Warn("Hub status changed", "target", e.address, "from", t.status, "to", status)
+		severity := events.Warning
+		if status == "operational" {
+			severity = events.Notice
+		}
+		e.emit(now, events.StatusChanged, severity,
+			fmt.Sprintf("Status changed from %s to %s", t.status, status),
+			events.Param{Name: "from", Value: t.status},
+			events.Param{Name: "to", Value: status},
+		)
+	}
+	t.status = status
+
+	if t.accessAllowed != nil && *t.accessAllowed != accessAllowed {
+		slog.Warn("Hub network access changed", "target", e.address, "access_allowed", accessAllowed)
+		severity := events.Warning
+		message := "Network access denied"
+		if accessAllowed {
+			severity = events.Notice
+			message = "Network access allowed"
+		}
+		e.emit(now, events.AccessAllowedChanged, severity, message,
+			events.Param{Name: "access_allowed", Value: strconv.FormatBool(accessAllowed)},
+		)
+	}
+	t.accessAllowed = &accessAllowed
+}
+
+// updateLocks records the lock status by channel ID of a direction, and reports channels which
+// lost or acquired lock. Channels which appear or disappear are reported as lineup changes
+// instead.
+func (t *transitionTracker) updateLocks(now time.Time, e *HubExporter, direction string, locks map[uint64]bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	previous := &t.downstreamLocks
+	if direction == "upstream" {
+		previous = &t.upstreamLocks
+	}
+
+	ids := make([]uint64, 0, len(locks))
+	for id := range locks {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		locked := locks[id]
+		previousLocked, ok := (*previous)[id]
+		if !ok || previousLocked == locked {
+			continue
+		}
+		slog.Warn("Channel lock status changed", "target", e.address, "direction", direction, "channel_id", id, "locked", locked)
+		eventType, severity, message := events.LockLost, events.Warning, "Lost lock"
+		if locked {
+			eventType, severity, message = events.LockAcquired, events.Notice, "Acquired lock"
+		}
+		e.emit(now, eventType, severity,
+			fmt.Sprintf("%s on %s channel %d", message, direction, id),
+			events.Param{Name: "direction", Value: direction},
+			events.Param{Name: "channel_id", Value: strconv.FormatUint(id, 10)},
+		)
+	}
+	*previous = locks
+}
+
+// detectTransitions reports changes of the cable modem status, network access and channel lock
+// status.
+func (e *HubExporter) detectTransitions(s *Scrape) {
+	if s.State != nil {
+		e.transitionTracker.updateState(s.Time, e, s.State.CableModem.Status, s.State.CableModem.AccessAllowed)
+	}
+	if s.Downstream != nil {
+		locks := map[uint64]bool{}
+		for _, c := range s.Downstream.DownstreamItem.DownstreamChannels {
+			locks[c.ChannelId] = c.LockStatus
+		}
+		e.transitionTracker.updateLocks(s.Time, e, "downstream", locks)
+	}
+	if s.Upstream != nil {
+		locks := map[uint64]bool{}
+		for _, c := range s.Upstream.UpstreamItem.Channels {
+			locks[c.ChannelId] = c.LockStatus
+		}
+		e.transitionTracker.updateLocks(s.Time, e, "upstream", locks)
+	}
+}
+
+// TransitionState is the persistent state of status, network access and lock status change
+// detection.
+type TransitionState struct {
+	Status          string          `json:"status"`
+	AccessAllowed   *bool           `json:"accessAllowed"`
+	DownstreamLocks map[uint64]bool `json:"downstreamLocks"`
+	UpstreamLocks   map[uint64]bool `json:"upstreamLocks"`
+}
+
+func (t *transitionTracker) state() TransitionState {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return TransitionState{
+		Status:          t.status,
+		AccessAllowed:   t.accessAllowed,
+		DownstreamLocks: t.downstreamLocks,
+		UpstreamLocks:   t.upstreamLocks,
+	}
+}
+
+func (t *transitionTracker) restore(s TransitionState) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.status = s.Status
+	t.accessAllowed = s.AccessAllowed
+	t.downstreamLocks = s.DownstreamLocks
+	t.upstreamLocks = s.UpstreamLocks
+}
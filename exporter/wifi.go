@@ -0,0 +1,40 @@
+package exporter
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// collectWifiRadios exports the status of the Hub Wi-Fi radios, which are only fetched when
+// logging in to the Hub.
+func (e *HubExporter) collectWifiRadios(ch chan<- prometheus.Metric, s *Scrape) {
+	if s.WifiRadios == nil {
+		if s.WifiRadiosErr != nil {
+			ch <- prometheus.MustNewConstMetric(e.descWifiUp, prometheus.GaugeValue, 0)
+		}
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(e.descWifiUp, prometheus.GaugeValue, 1)
+
+	for _, r := range s.WifiRadios.WifiRadiosItem.Radios {
+		// A band may have multiple radios (eg: guest or backhaul)
+		id := strconv.FormatUint(r.Id, 10)
+		ch <- prometheus.MustNewConstMetric(
+			e.descWifiRadioInfo, prometheus.GaugeValue, 1,
+			id, r.Band, r.Status, r.Mode, strconv.FormatBool(r.AutoChannel),
+		)
+		enabled := 0.0
+		if r.Enable {
+			enabled = 1.0
+		}
+		ch <- prometheus.MustNewConstMetric(e.descWifiRadioEnabled, prometheus.GaugeValue, enabled, id, r.Band)
+		ch <- prometheus.MustNewConstMetric(e.descWifiRadioChannel, prometheus.GaugeValue, float64(r.Channel), id, r.Band)
+		ch <- prometheus.MustNewConstMetric(e.descWifiRadioBandwidth, prometheus.GaugeValue, float64(r.ChannelBandwidth), id, r.Band)
+		if r.TransmitPower != nil {
+			ch <- prometheus.MustNewConstMetric(e.descWifiRadioTxPower, prometheus.GaugeValue, float64(*r.TransmitPower), id, r.Band)
+		}
+		ch <- prometheus.MustNewConstMetric(e.descWifiRadioSsids, prometheus.GaugeValue, float64(r.NumberOfSsids), id, r.Band)
+		ch <- prometheus.MustNewConstMetric(e.descWifiRadioClients, prometheus.GaugeValue, float64(r.ConnectedClients), id, r.Band)
+	}
+}
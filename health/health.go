@@ -0,0 +1,196 @@
+package health
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	hub6 "github.com/fornellas/virginmedia_hub6_exporter/hub6"
+)
+
+// Grade of a channel signal quality.
+type Grade int
+
+const (
+	Ok Grade = iota
+	Warn
+	Critical
+)
+
+func (g Grade) String() string {
+	switch g {
+	case Ok:
+		return "ok"
+	case Warn:
+		return "warn"
+	case Critical:
+		return "critical"
+	default:
+		return "unknown"
+	}
+}
+
+func worst(grades ...Grade) Grade {
+	w := Ok
+	for _, g := range grades {
+		if g > w {
+			w = g
+		}
+	}
+	return w
+}
+
+// Window grades a value by whether it is within an ok range, a wider warn range, or outside
+// both (critical).
+type Window struct {
+	OkMin   float64 `json:"okMin"`
+	OkMax   float64 `json:"okMax"`
+	WarnMin float64 `json:"warnMin"`
+	WarnMax float64 `json:"warnMax"`
+}
+
+func (w Window) grade(v float64) Grade {
+	if v >= w.OkMin && v <= w.OkMax {
+		return Ok
+	}
+	if v >= w.WarnMin && v <= w.WarnMax {
+		return Warn
+	}
+	return Critical
+}
+
+// Minimum grades a value by whether it is at least an ok value, at least a warn value or
+// below both (critical).
+type Minimum struct {
+	Ok   float64 `json:"ok"`
+	Warn float64 `json:"warn"`
+}
+
+func (m Minimum) grade(v float64) Grade {
+	if v >= m.Ok {
+		return Ok
+	}
+	if v >= m.Warn {
+		return Warn
+	}
+	return Critical
+}
+
+// Thresholds used to grade channels. Each is keyed by modulation (eg: qam_256), with the
+// empty key used for modulations without an entry.
+type Thresholds struct {
+	// Downstream receive power window (dBmV)
+	DownstreamPower map[string]Window `json:"downstreamPower"`
+	// Minimum downstream SNR for SC-QAM, or RxMER for OFDM (dB)
+	DownstreamMer map[string]Minimum `json:"downstreamMer"`
+	// Upstream transmit power window (dBmV); its maximum is the transmit power ceiling, above
+	// which the modem is running out of headroom.
+	UpstreamPower map[string]Window `json:"upstreamPower"`
+}
+
+// DefaultThresholds returns thresholds based on DOCSIS specifications and commonly used ISP
+// recommendations.
+func DefaultThresholds() Thresholds {
+	return Thresholds{
+		DownstreamPower: map[string]Window{
+			"":         {OkMin: -6, OkMax: 10, WarnMin: -10, WarnMax: 15},
+			"qam_64":   {OkMin: -8, OkMax: 10, WarnMin: -12, WarnMax: 15},
+			"qam_256":  {OkMin: -6, OkMax: 10, WarnMin: -10, WarnMax: 15},
+			"qam_4096": {OkMin: -6, OkMax: 10, WarnMin: -10, WarnMax: 15},
+		},
+		DownstreamMer: map[string]Minimum{
+			"":         {Ok: 33, Warn: 30},
+			"qam_64":   {Ok: 27, Warn: 24},
+			"qam_256":  {Ok: 33, Warn: 30},
+			"qam_1024": {Ok: 36, Warn: 33},
+			"qam_4096": {Ok: 40, Warn: 36},
+		},
+		UpstreamPower: map[string]Window{
+			"":       {OkMin: 35, OkMax: 49, WarnMin: 30, WarnMax: 51},
+			"qpsk":   {OkMin: 35, OkMax: 52, WarnMin: 30, WarnMax: 54},
+			"qam_16": {OkMin: 35, OkMax: 52, WarnMin: 30, WarnMax: 54},
+			"qam_32": {OkMin: 35, OkMax: 51, WarnMin: 30, WarnMax: 53},
+			"qam_64": {OkMin: 35, OkMax: 49, WarnMin: 30, WarnMax: 51},
+		},
+	}
+}
+
+// thresholdsFile is the format of the file read by LoadThresholds: the same as Thresholds,
+// with entries decoded over their defaults.
+type thresholdsFile struct {
+	DownstreamPower map[string]json.RawMessage `json:"downstreamPower"`
+	DownstreamMer   map[string]json.RawMessage `json:"downstreamMer"`
+	UpstreamPower   map[string]json.RawMessage `json:"upstreamPower"`
+}
+
+// overlay decodes each of entries over the entry for the same modulation in m, or over the
+// one for modulations without an entry, so only the fields present are overridden.
+func overlay[V any](m map[string]V, entries map[string]json.RawMessage) error {
+	modulations := make([]string, 0, len(entries))
+	for modulation := range entries {
+		modulations = append(modulations, modulation)
+	}
+	// The entry for modulations without one goes first, as new modulations start from it
+	sort.Strings(modulations)
+	for _, modulation := range modulations {
+		v := lookup(m, modulation)
+		if err := json.Unmarshal(entries[modulation], &v); err != nil {
+			return fmt.Errorf("%#v: %w", modulation, err)
+		}
+		m[modulation] = v
+	}
+	return nil
+}
+
+// LoadThresholds reads thresholds from a JSON file at path, in the same format as Thresholds.
+// Entries in the file override the ones from DefaultThresholds, field by field: fields missing
+// from an entry keep their default value, or for new modulations, the value from the entry
+// for modulations without one.
+func LoadThresholds(path string) (Thresholds, error) {
+	t := DefaultThresholds()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return t, err
+	}
+	var f thresholdsFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return t, err
+	}
+	if err := overlay(t.DownstreamPower, f.DownstreamPower); err != nil {
+		return t, fmt.Errorf("downstreamPower: %w", err)
+	}
+	if err := overlay(t.DownstreamMer, f.DownstreamMer); err != nil {
+		return t, fmt.Errorf("downstreamMer: %w", err)
+	}
+	if err := overlay(t.UpstreamPower, f.UpstreamPower); err != nil {
+		return t, fmt.Errorf("upstreamPower: %w", err)
+	}
+	return t, nil
+}
+
+func lookup[V any](m map[string]V, modulation string) V {
+	if v, ok := m[modulation]; ok {
+		return v
+	}
+	return m[""]
+}
+
+// Downstream grades a downstream channel by its lock status, power and SNR / RxMER.
+func (t Thresholds) Downstream(c hub6.DownstreamChannel) Grade {
+	if !c.LockStatus {
+		return Critical
+	}
+	return worst(
+		lookup(t.DownstreamPower, c.Modulation).grade(c.PowerDbmv()),
+		lookup(t.DownstreamMer, c.Modulation).grade(c.MerDb()),
+	)
+}
+
+// Upstream grades an upstream channel by its lock status and transmit power.
+func (t Thresholds) Upstream(c hub6.UpstreamChannel) Grade {
+	if !c.LockStatus {
+		return Critical
+	}
+	return lookup(t.UpstreamPower, c.Modulation).grade(c.Power)
+}
@@ -0,0 +1,185 @@
+package health
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	hub6 "github.com/fornellas/virginmedia_hub6_exporter/hub6"
+)
+
+func TestWindowGrade(t *testing.T) {
+	w := Window{OkMin: -6, OkMax: 10, WarnMin: -10, WarnMax: 15}
+	for _, tc := range []struct {
+		value    float64
+		expected Grade
+	}{
+		{0, Ok},
+		{-6, Ok},
+		{10, Ok},
+		{-8, Warn},
+		{12, Warn},
+		{-10, Warn},
+		{15, Warn},
+		{-10.1, Critical},
+		{15.1, Critical},
+	} {
+		if got := w.grade(tc.value); got != tc.expected {
+			t.Errorf("grade(%v): got %s, expected %s", tc.value, got, tc.expected)
+		}
+	}
+}
+
+func TestMinimumGrade(t *testing.T) {
+	m := Minimum{Ok: 33, Warn: 30}
+	for _, tc := range []struct {
+		value    float64
+		expected Grade
+	}{
+		{40, Ok},
+		{33, Ok},
+		{31, Warn},
+		{30, Warn},
+		{29.9, Critical},
+	} {
+		if got := m.grade(tc.value); got != tc.expected {
+			t.Errorf("grade(%v): got %s, expected %s", tc.value, got, tc.expected)
+		}
+	}
+}
+
+func TestThresholdsDownstream(t *testing.T) {
+	thresholds := DefaultThresholds()
+	for _, tc := range []struct {
+		name     string
+		channel  hub6.DownstreamChannel
+		expected Grade
+	}{
+		{
+			name:     "ok",
+			channel:  hub6.DownstreamChannel{ChannelType: "sc_qam", Modulation: "qam_256", LockStatus: true, Power: 3, Snr: 38},
+			expected: Ok,
+		},
+		{
+			name:     "unlocked",
+			channel:  hub6.DownstreamChannel{ChannelType: "sc_qam", Modulation: "qam_256", Power: 3, Snr: 38},
+			expected: Critical,
+		},
+		{
+			name:     "worst of power and SNR",
+			channel:  hub6.DownstreamChannel{ChannelType: "sc_qam", Modulation: "qam_256", LockStatus: true, Power: 12, Snr: 20},
+			expected: Critical,
+		},
+		{
+			name:     "per modulation thresholds",
+			channel:  hub6.DownstreamChannel{ChannelType: "sc_qam", Modulation: "qam_64", LockStatus: true, Power: -7, Snr: 28},
+			expected: Ok,
+		},
+		{
+			name:     "unknown modulations use the default entry",
+			channel:  hub6.DownstreamChannel{ChannelType: "sc_qam", Modulation: "qam_128", LockStatus: true, Power: -7, Snr: 28},
+			expected: Critical,
+		},
+		{
+			// Power and RxMER are in tenths
+			name:     "OFDM",
+			channel:  hub6.DownstreamChannel{ChannelType: "ofdm", Modulation: "qam_4096", LockStatus: true, Power: 52, RxMer: 380},
+			expected: Warn,
+		},
+	} {
+		if got := thresholds.Downstream(tc.channel); got != tc.expected {
+			t.Errorf("%s: got %s, expected %s", tc.name, got, tc.expected)
+		}
+	}
+}
+
+func TestThresholdsUpstream(t *testing.T) {
+	thresholds := DefaultThresholds()
+	for _, tc := range []struct {
+		name     string
+		channel  hub6.UpstreamChannel
+		expected Grade
+	}{
+		{"ok", hub6.UpstreamChannel{Modulation: "qam_64", LockStatus: true, Power: 44}, Ok},
+		{"unlocked", hub6.UpstreamChannel{Modulation: "qam_64", Power: 44}, Critical},
+		{"near the ceiling", hub6.UpstreamChannel{Modulation: "qam_64", LockStatus: true, Power: 50}, Warn},
+		{"per modulation thresholds", hub6.UpstreamChannel{Modulation: "qpsk", LockStatus: true, Power: 52}, Ok},
+		{"unknown modulations use the default entry", hub6.UpstreamChannel{Modulation: "qam_128", LockStatus: true, Power: 52}, Critical},
+	} {
+		if got := thresholds.Upstream(tc.channel); got != tc.expected {
+			t.Errorf("%s: got %s, expected %s", tc.name, got, tc.expected)
+		}
+	}
+}
+
+func TestLoadThresholds(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		content  string
+		expected func(t *Thresholds)
+		err      string
+	}{
+		{
+			name:     "empty",
+			content:  `{}`,
+			expected: func(t *Thresholds) {},
+		},
+		{
+			name:    "partial override keeps the omitted fields",
+			content: `{"downstreamPower": {"qam_256": {"okMax": 8}}, "downstreamMer": {"qam_4096": {"warn": 35}}}`,
+			expected: func(t *Thresholds) {
+				t.DownstreamPower["qam_256"] = Window{OkMin: -6, OkMax: 8, WarnMin: -10, WarnMax: 15}
+				t.DownstreamMer["qam_4096"] = Minimum{Ok: 40, Warn: 35}
+			},
+		},
+		{
+			name: "new modulations start from the overridden default entry",
+			content: `{"upstreamPower": {
+				"qam_128": {"okMax": 48},
+				"": {"warnMax": 50}
+			}}`,
+			expected: func(t *Thresholds) {
+				t.UpstreamPower[""] = Window{OkMin: 35, OkMax: 49, WarnMin: 30, WarnMax: 50}
+				t.UpstreamPower["qam_128"] = Window{OkMin: 35, OkMax: 48, WarnMin: 30, WarnMax: 50}
+			},
+		},
+		{
+			name:    "invalid entry",
+			content: `{"downstreamMer": {"qam_64": {"ok": "high"}}}`,
+			err:     `downstreamMer: "qam_64": `,
+		},
+		{
+			name:    "invalid JSON",
+			content: `{`,
+			err:     "unexpected end of JSON input",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "thresholds.json")
+			if err := os.WriteFile(path, []byte(tc.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			thresholds, err := LoadThresholds(path)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("got error %v, expected %q", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := DefaultThresholds()
+			tc.expected(&expected)
+			if !reflect.DeepEqual(thresholds, expected) {
+				t.Errorf("got %+v, expected %+v", thresholds, expected)
+			}
+		})
+	}
+
+	if _, err := LoadThresholds(filepath.Join(t.TempDir(), "missing.json")); !os.IsNotExist(err) {
+		t.Errorf("got error %v, expected not exist", err)
+	}
+}
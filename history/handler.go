@@ -0,0 +1,51 @@
+package history
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+	"sort"
+)
+
+//go:embed dashboard.html
+var dashboardHTML []byte
+
+// Handler serves a self-contained HTML dashboard at its root, charting histories, and their
+// data as JSON at data: the samples of the target given by the "target" parameter, or the
+// list of targets if it is not given.
+func Handler(histories map[string]*History) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(dashboardHTML)
+	})
+
+	mux.HandleFunc("/data", func(w http.ResponseWriter, r *http.Request) {
+		target := r.URL.Query().Get("target")
+
+		var data any
+		if target == "" {
+			targets := make([]string, 0, len(histories))
+			for target := range histories {
+				targets = append(targets, target)
+			}
+			sort.Strings(targets)
+			data = map[string][]string{"targets": targets}
+		} else {
+			history, ok := histories[target]
+			if !ok {
+				http.Error(w, "unknown target", http.StatusNotFound)
+				return
+			}
+			data = map[string][]Sample{"samples": history.Samples()}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(data); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+
+	return mux
+}
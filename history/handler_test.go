@@ -0,0 +1,65 @@
+package history
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func get(t *testing.T, handler http.Handler, url string) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", url, nil))
+	return w
+}
+
+func TestHandler(t *testing.T) {
+	hub1 := NewHistory(2)
+	hub1.Add(newScrape(1))
+	hub1.Add(newScrape(2))
+	hub1.Add(newScrape(3))
+	handler := Handler(map[string]*History{
+		"hub2": NewHistory(2),
+		"hub1": hub1,
+	})
+
+	w := get(t, handler, "/")
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/html") {
+		t.Errorf("dashboard: got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+
+	w = get(t, handler, "/data")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("targets: got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	var targets struct {
+		Targets []string `json:"targets"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&targets); err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"hub1", "hub2"}; !reflect.DeepEqual(targets.Targets, expected) {
+		t.Errorf("targets: got %v, expected %v", targets.Targets, expected)
+	}
+
+	w = get(t, handler, "/data?target=hub1")
+	if w.Code != http.StatusOK {
+		t.Fatalf("samples: got %d", w.Code)
+	}
+	var samples struct {
+		Samples []Sample `json:"samples"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&samples); err != nil {
+		t.Fatal(err)
+	}
+	if got := uptimes(samples.Samples); !reflect.DeepEqual(got, []uint64{2, 3}) {
+		t.Errorf("samples: got %v, expected [2 3]", got)
+	}
+
+	if w = get(t, handler, "/data?target=hub3"); w.Code != http.StatusNotFound {
+		t.Errorf("unknown target: got %d, expected %d", w.Code, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,109 @@
+package history
+
+import (
+	"sync"
+	"time"
+
+	"github.com/fornellas/virginmedia_hub6_exporter/exporter"
+)
+
+// Channel is a sample of a single channel.
+type Channel struct {
+	ChannelId  uint64  `json:"channelId"`
+	LockStatus bool    `json:"lockStatus"`
+	Power      float64 `json:"power"`
+	// Downstream only: SNR (RxMER for OFDM)
+	Snr               float64 `json:"snr"`
+	CorrectedErrors   uint64  `json:"correctedErrors"`
+	UncorrectedErrors uint64  `json:"uncorrectedErrors"`
+	// Upstream only
+	T3Timeouts uint64 `json:"t3Timeouts"`
+	T4Timeouts uint64 `json:"t4Timeouts"`
+}
+
+// Sample is a compact record of a scrape.
+type Sample struct {
+	Time time.Time `json:"time"`
+	// Whether all endpoints were scraped successfully
+	Up bool `json:"up"`
+	// Empty if the state endpoint failed
+	Status        string    `json:"status,omitempty"`
+	UptimeSeconds uint64    `json:"uptimeSeconds"`
+	Downstream    []Channel `json:"downstream,omitempty"`
+	Upstream      []Channel `json:"upstream,omitempty"`
+}
+
+func newSample(s *exporter.Scrape) Sample {
+	sample := Sample{
+		Time: s.Time,
+		Up:   s.Err() == nil,
+	}
+	if s.State != nil {
+		sample.Status = s.State.CableModem.Status
+		sample.UptimeSeconds = s.State.CableModem.UpTime
+	}
+	if s.Downstream != nil {
+		for _, c := range s.Downstream.DownstreamItem.DownstreamChannels {
+			sample.Downstream = append(sample.Downstream, Channel{
+				ChannelId:         c.ChannelId,
+				LockStatus:        c.LockStatus,
+				Power:             c.PowerDbmv(),
+				Snr:               c.MerDb(),
+				CorrectedErrors:   c.CorrectedErrors,
+				UncorrectedErrors: c.UncorrectedErrors,
+			})
+		}
+	}
+	if s.Upstream != nil {
+		for _, c := range s.Upstream.UpstreamItem.Channels {
+			sample.Upstream = append(sample.Upstream, Channel{
+				ChannelId:  c.ChannelId,
+				LockStatus: c.LockStatus,
+				Power:      c.Power,
+				T3Timeouts: c.T3Timeout,
+				T4Timeouts: c.T4Timeout,
+			})
+		}
+	}
+	return sample
+}
+
+// History is a ring buffer of the most recent samples of a Hub.
+type History struct {
+	mu      sync.Mutex
+	samples []Sample
+	// Index where the next sample is written
+	next int
+	full bool
+}
+
+// NewHistory creates a History holding up to capacity samples (at least one).
+func NewHistory(capacity int) *History {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &History{
+		samples: make([]Sample, capacity),
+	}
+}
+
+// Add records s, discarding the oldest sample if full.
+func (h *History) Add(s *exporter.Scrape) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.samples[h.next] = newSample(s)
+	h.next = (h.next + 1) % len(h.samples)
+	if h.next == 0 {
+		h.full = true
+	}
+}
+
+// Samples returns all samples, oldest first.
+func (h *History) Samples() []Sample {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.full {
+		return append([]Sample{}, h.samples[:h.next]...)
+	}
+	return append(append([]Sample{}, h.samples[h.next:]...), h.samples[:h.next]...)
+}
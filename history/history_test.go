@@ -0,0 +1,100 @@
+package history
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fornellas/virginmedia_hub6_exporter/exporter"
+	hub6 "github.com/fornellas/virginmedia_hub6_exporter/hub6"
+)
+
+func newScrape(uptime uint64) *exporter.Scrape {
+	return &exporter.Scrape{
+		Time:  time.Unix(1700000000+int64(uptime), 0),
+		State: &hub6.State{CableModem: hub6.CableModem{Status: "operational", UpTime: uptime}},
+	}
+}
+
+func uptimes(samples []Sample) []uint64 {
+	var u []uint64
+	for _, s := range samples {
+		u = append(u, s.UptimeSeconds)
+	}
+	return u
+}
+
+func TestHistory(t *testing.T) {
+	h := NewHistory(3)
+	for i, expected := range [][]uint64{
+		{1},
+		{1, 2},
+		{1, 2, 3},
+		// Full: the oldest sample is discarded
+		{2, 3, 4},
+		{3, 4, 5},
+		{4, 5, 6},
+		{5, 6, 7},
+	} {
+		h.Add(newScrape(uint64(i + 1)))
+		if got := uptimes(h.Samples()); !reflect.DeepEqual(got, expected) {
+			t.Errorf("after %d samples: got %v, expected %v", i+1, got, expected)
+		}
+	}
+}
+
+func TestHistoryEmpty(t *testing.T) {
+	if samples := NewHistory(3).Samples(); len(samples) != 0 {
+		t.Errorf("got %v, expected no samples", samples)
+	}
+}
+
+func TestHistoryMinimumCapacity(t *testing.T) {
+	h := NewHistory(0)
+	h.Add(newScrape(1))
+	h.Add(newScrape(2))
+	if got := uptimes(h.Samples()); !reflect.DeepEqual(got, []uint64{2}) {
+		t.Errorf("got %v, expected [2]", got)
+	}
+}
+
+func TestHistorySamplesCopy(t *testing.T) {
+	h := NewHistory(2)
+	h.Add(newScrape(1))
+	samples := h.Samples()
+	samples[0].UptimeSeconds = 100
+	if got := uptimes(h.Samples()); !reflect.DeepEqual(got, []uint64{1}) {
+		t.Errorf("got %v, expected [1]", got)
+	}
+}
+
+func TestNewSample(t *testing.T) {
+	s := newScrape(3600)
+	s.Downstream = &hub6.Downstream{DownstreamItem: hub6.DownstreamItem{DownstreamChannels: []hub6.DownstreamChannel{
+		{ChannelType: "sc_qam", ChannelId: 1, Power: 4.5, Snr: 40, LockStatus: true, CorrectedErrors: 10, UncorrectedErrors: 1},
+		// The Hub reports OFDM power and RxMER in tenths
+		{ChannelType: "ofdm", ChannelId: 33, Power: 52, RxMer: 380, LockStatus: true},
+	}}}
+	s.Upstream = &hub6.Upstream{UpstreamItem: hub6.UpstreamItem{Channels: []hub6.UpstreamChannel{
+		{ChannelId: 2, Power: 44, LockStatus: true, T3Timeout: 3, T4Timeout: 1},
+	}}}
+	s.ServiceFlowsErr = errors.New("timeout")
+
+	expected := Sample{
+		Time:          s.Time,
+		Up:            false,
+		Status:        "operational",
+		UptimeSeconds: 3600,
+		Downstream: []Channel{
+			{ChannelId: 1, LockStatus: true, Power: 4.5, Snr: 40, CorrectedErrors: 10, UncorrectedErrors: 1},
+			{ChannelId: 33, LockStatus: true, Power: 5.2, Snr: 38},
+		},
+		Upstream: []Channel{
+			{ChannelId: 2, LockStatus: true, Power: 44, T3Timeouts: 3, T4Timeouts: 1},
+		},
+	}
+	if sample := newSample(s); !reflect.DeepEqual(sample, expected) {
+		t.Errorf("got %+v, expected %+v", sample, expected)
+	}
+}
@@ -0,0 +1,54 @@
+package exporter
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+// BootFile holds the provisioned configuration encoded in the boot filename, eg:
+// F3896LG_cm_res008_nowifi_v4.bin. Fields are empty when not present in the filename.
+type BootFile struct {
+	// Modem model, eg: F3896LG
+	Model string
+	// Customer segment, eg: res (residential) or bus (business)
+	Segment string
+	// Speed tier within the segment, eg: 008
+	Tier string
+	// on / off (modem mode)
+	Wifi string
+	// IP provisioning mode, eg: v4
+	IpMode string
+}
+
+var (
+	bootFileTierRegexp   = regexp.MustCompile(`^([a-z]+)([0-9]+)$`)
+	bootFileIpModeRegexp = regexp.MustCompile(`^v[0-9]+$`)
+)
+
+// ParseBootFilename parses the provisioned configuration from a boot filename.
+func ParseBootFilename(name string) BootFile {
+	var b BootFile
+	name = strings.TrimSuffix(path.Base(name), ".bin")
+	tokens := strings.Split(name, "_")
+	if len(tokens) < 2 {
+		return b
+	}
+	b.Model = tokens[0]
+	for _, token := range tokens[1:] {
+		token = strings.ToLower(token)
+		switch {
+		case token == "nowifi":
+			b.Wifi = "off"
+		case token == "wifi":
+			b.Wifi = "on"
+		case bootFileIpModeRegexp.MatchString(token):
+			b.IpMode = token
+		case b.Segment == "":
+			if m := bootFileTierRegexp.FindStringSubmatch(token); m != nil {
+				b.Segment, b.Tier = m[1], m[2]
+			}
+		}
+	}
+	return b
+}
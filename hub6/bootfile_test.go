@@ -0,0 +1,34 @@
+package exporter
+
+import "testing"
+
+func TestParseBootFilename(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		expected BootFile
+	}{
+		{
+			"F3896LG_cm_res008_nowifi_v4.bin",
+			BootFile{Model: "F3896LG", Segment: "res", Tier: "008", Wifi: "off", IpMode: "v4"},
+		},
+		{
+			"configs/F3896LG_CM_BUS100_WIFI_V6.bin",
+			BootFile{Model: "F3896LG", Segment: "bus", Tier: "100", Wifi: "on", IpMode: "v6"},
+		},
+		// Only the first tier is used
+		{
+			"F3896LG_res008_res010",
+			BootFile{Model: "F3896LG", Segment: "res", Tier: "008"},
+		},
+		{
+			"F3896LG_unknown",
+			BootFile{Model: "F3896LG"},
+		},
+		{"F3896LG.bin", BootFile{}},
+		{"", BootFile{}},
+	} {
+		if got := ParseBootFilename(tc.name); got != tc.expected {
+			t.Errorf("ParseBootFilename(%q): got %+v, expected %+v", tc.name, got, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// anonymousPrefix is the prefix of resources which can be reached without logging in.
+const anonymousPrefix = "/rest/v1/cablemodem/"
+
+// POST http://${address}/rest/v1/user/login
+type LoginRequest struct {
+	Password string `json:"password"`
+}
+
+type LoginCreated struct {
+	Token     string `json:"token"`
+	UserLevel string `json:"userLevel"`
+	UserId    uint64 `json:"userId"`
+}
+
+type LoginResponse struct {
+	Created LoginCreated `json:"created"`
+}
+
+// Client queries the REST API of a Hub. The /rest/v1/cablemodem/* resources are anonymous; if
+// a password is set, the Client also logs in as admin to reach other resources, protected in
+// router mode. The session token is reused until the Hub rejects it. Close must be called when
+// done, to log out.
+type Client struct {
+	address  string
+	client   *http.Client
+	password string
+
+	mu     sync.Mutex
+	token  string
+	userId uint64
+}
+
+// NewClient creates a new Client for the hub at address. timeout is applied to each HTTP
+// request.
+func NewClient(address string, timeout time.Duration) *Client {
+	return &Client{
+		address: address,
+		client:  &http.Client{Timeout: timeout},
+	}
+}
+
+// Password sets the admin password used to log in to the Hub.
+func (c *Client) Password(password string) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.password = password
+	c.token = ""
+	return c
+}
+
+func (c *Client) url(path string) string {
+	return fmt.Sprintf("http://%s%s", c.address, path)
+}
+
+// login returns the cached session token, logging in to the Hub if there's none, or force is
+// set. An empty token is returned if no password is set.
+func (c *Client) login(ctx context.Context, force bool) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.password == "" {
+		return "", nil
+	}
+	if !force && c.token != "" {
+		return c.token, nil
+	}
+	c.token = ""
+
+	url := c.url("/rest/v1/user/login")
+	body, err := json.Marshal(LoginRequest{Password: c.password})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to login: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("failed to login: unexpected status %d from %s", resp.StatusCode, url)
+	}
+
+	var loginResponse LoginResponse
+	if err := json.NewDecoder(resp.Body).Decode(&loginResponse); err != nil {
+		return "", fmt.Errorf("failed to login: failed to decode JSON from %s: %w", url, err)
+	}
+	if loginResponse.Created.Token == "" {
+		return "", fmt.Errorf("failed to login: no token in response from %s", url)
+	}
+
+	c.token = loginResponse.Created.Token
+	c.userId = loginResponse.Created.UserId
+	return c.token, nil
+}
+
+// Close logs out of the Hub, if logged in, with
+// DELETE http://${address}/rest/v1/user/${userId}/token/${token}
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token == "" {
+		return nil
+	}
+	token := c.token
+	c.token = ""
+
+	url := c.url(fmt.Sprintf("/rest/v1/user/%d/token/%s", c.userId, token))
+	req, err := http.NewRequest("DELETE", url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to logout: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to logout: unexpected status %d from %s", resp.StatusCode, c.url("/rest/v1/user"))
+	}
+	return nil
+}
+
+func (c *Client) do(ctx context.Context, path, token string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.url(path), nil)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return c.client.Do(req)
+}
+
+// Get fetches path from the Hub, and decodes its JSON body into out. Resources other than the
+// anonymous /rest/v1/cablemodem/* ones are fetched logged in, if a password is set; if the Hub
+// replies 401, the Client logs in again and retries once.
+func (c *Client) Get(ctx context.Context, path string, out any) error {
+	url := c.url(path)
+
+	// Anonymous resources remain reachable when logging in fails
+	protected := !strings.HasPrefix(path, anonymousPrefix) && c.HasPassword()
+
+	var token string
+	if protected {
+		var err error
+		if token, err = c.login(ctx, false); err != nil {
+			return err
+		}
+	}
+
+	resp, err := c.do(ctx, path, token)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized && protected {
+		resp.Body.Close()
+		token, err = c.login(ctx, true)
+		if err != nil {
+			return err
+		}
+		resp, err = c.do(ctx, path, token)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, url)
+	}
+
+	// Read the full body so we can attempt a strict decode first, then fall back
+	// to a lenient unmarshal if necessary.
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read body from %s: %w", url, err)
+	}
+
+	// Attempt strict decoding (disallow unknown fields).
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(out); err == nil {
+		return nil
+	}
+
+	// Fallback: lenient unmarshal (allows unknown fields).
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode JSON from %s (strict then lenient): %w", url, err)
+	}
+	return nil
+}
+
+// HasPassword returns whether a password is set, so the Client logs in to the Hub.
+func (c *Client) HasPassword() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.password != ""
+}
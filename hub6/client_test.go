@@ -0,0 +1,196 @@
+package exporter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeHub is a Hub REST API, which issues a new session token on every login.
+type fakeHub struct {
+	password string
+
+	mu       sync.Mutex
+	logins   int
+	token    string
+	requests []string
+}
+
+func (h *fakeHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	request := r.Method + " " + r.URL.Path
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		request += " " + auth
+	}
+	h.requests = append(h.requests, request)
+
+	switch {
+	case r.Method == "POST" && r.URL.Path == "/rest/v1/user/login":
+		var login LoginRequest
+		if err := json.NewDecoder(r.Body).Decode(&login); err != nil || login.Password != h.password {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		h.logins++
+		h.token = fmt.Sprintf("token%d", h.logins)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(LoginResponse{Created: LoginCreated{Token: h.token, UserLevel: "admin", UserId: 7}})
+	case r.Method == "DELETE" && strings.HasPrefix(r.URL.Path, "/rest/v1/user/"):
+		if h.token == "" || r.URL.Path != "/rest/v1/user/7/token/"+h.token {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		h.token = ""
+	case r.Method == "GET" && strings.HasPrefix(r.URL.Path, anonymousPrefix):
+		json.NewEncoder(w).Encode(State{CableModem: CableModem{Status: "operational"}})
+	case r.Method == "GET":
+		if h.token == "" || r.Header.Get("Authorization") != "Bearer "+h.token {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(State{CableModem: CableModem{Status: "operational"}})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// expire invalidates the current session token, as the Hub does after a while.
+func (h *fakeHub) expire() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.token = ""
+}
+
+// takeRequests returns the requests received since the last call.
+func (h *fakeHub) takeRequests() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	requests := h.requests
+	h.requests = nil
+	return requests
+}
+
+func newFakeHub(t *testing.T, password string) (*fakeHub, string) {
+	t.Helper()
+	hub := &fakeHub{password: password}
+	server := httptest.NewServer(hub)
+	t.Cleanup(server.Close)
+	return hub, strings.TrimPrefix(server.URL, "http://")
+}
+
+func get(t *testing.T, c *Client, path string) {
+	t.Helper()
+	var state State
+	if err := c.Get(context.Background(), path, &state); err != nil {
+		t.Fatalf("%s: %v", path, err)
+	}
+	if state.CableModem.Status != "operational" {
+		t.Fatalf("%s: got %+v", path, state)
+	}
+}
+
+func TestClientLogin(t *testing.T) {
+	hub, address := newFakeHub(t, "secret")
+	c := NewClient(address, time.Second).Password("secret")
+
+	// The token is reused
+	get(t, c, "/rest/v1/network/hosts")
+	get(t, c, "/rest/v1/network/hosts")
+	expected := []string{
+		"POST /rest/v1/user/login",
+		"GET /rest/v1/network/hosts Bearer token1",
+		"GET /rest/v1/network/hosts Bearer token1",
+	}
+	if requests := hub.takeRequests(); !reflect.DeepEqual(requests, expected) {
+		t.Errorf("got %q, expected %q", requests, expected)
+	}
+
+	// Logs in again and retries when the token is rejected
+	hub.expire()
+	get(t, c, "/rest/v1/network/hosts")
+	expected = []string{
+		"GET /rest/v1/network/hosts Bearer token1",
+		"POST /rest/v1/user/login",
+		"GET /rest/v1/network/hosts Bearer token2",
+	}
+	if requests := hub.takeRequests(); !reflect.DeepEqual(requests, expected) {
+		t.Errorf("got %q, expected %q", requests, expected)
+	}
+
+	// Anonymous resources are fetched without the token
+	get(t, c, "/rest/v1/cablemodem/state_")
+	expected = []string{"GET /rest/v1/cablemodem/state_"}
+	if requests := hub.takeRequests(); !reflect.DeepEqual(requests, expected) {
+		t.Errorf("got %q, expected %q", requests, expected)
+	}
+
+	// Logs out
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	expected = []string{"DELETE /rest/v1/user/7/token/token2 Bearer token2"}
+	if requests := hub.takeRequests(); !reflect.DeepEqual(requests, expected) {
+		t.Errorf("got %q, expected %q", requests, expected)
+	}
+	// Nothing left to log out of
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if requests := hub.takeRequests(); len(requests) != 0 {
+		t.Errorf("got %q, expected no requests", requests)
+	}
+}
+
+func TestClientWrongPassword(t *testing.T) {
+	hub, address := newFakeHub(t, "secret")
+	c := NewClient(address, time.Second).Password("wrong")
+
+	var state State
+	if err := c.Get(context.Background(), "/rest/v1/network/hosts", &state); err == nil {
+		t.Error("expected error")
+	}
+
+	// Anonymous resources remain reachable
+	hub.takeRequests()
+	get(t, c, "/rest/v1/cablemodem/state_")
+	expected := []string{"GET /rest/v1/cablemodem/state_"}
+	if requests := hub.takeRequests(); !reflect.DeepEqual(requests, expected) {
+		t.Errorf("got %q, expected %q", requests, expected)
+	}
+
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClientWithoutPassword(t *testing.T) {
+	hub, address := newFakeHub(t, "secret")
+	c := NewClient(address, time.Second)
+
+	get(t, c, "/rest/v1/cablemodem/state_")
+	// Protected resources are fetched without logging in, and rejected
+	var state State
+	if err := c.Get(context.Background(), "/rest/v1/network/hosts", &state); err == nil {
+		t.Error("expected error")
+	}
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"GET /rest/v1/cablemodem/state_",
+		"GET /rest/v1/network/hosts",
+	}
+	if requests := hub.takeRequests(); !reflect.DeepEqual(requests, expected) {
+		t.Errorf("got %q, expected %q", requests, expected)
+	}
+}
@@ -19,6 +19,8 @@ type DownstreamChannel struct {
 	CorrectedErrors uint64 `json:"correctedErrors"`
 	// Post RS Errors
 	UncorrectedErrors uint64 `json:"uncorrectedErrors"`
+	// Unerrored Codewords (not reported by all firmware versions)
+	UnerroredCodewords *uint64 `json:"unerroredCodewords,omitempty"`
 	// Locked Status
 	LockStatus bool `json:"lockStatus"`
 }
@@ -31,3 +33,21 @@ type DownstreamItem struct {
 type Downstream struct {
 	DownstreamItem DownstreamItem `json:"downstream"`
 }
+
+// PowerDbmv returns the channel power in dBmV. The Hub reports OFDM channel power in tenths of
+// dBmV.
+func (c DownstreamChannel) PowerDbmv() float64 {
+	if c.ChannelType == "ofdm" {
+		return c.Power / 10
+	}
+	return c.Power
+}
+
+// MerDb returns the channel signal quality in dB: the SNR for SC-QAM channels or the RxMER for
+// OFDM channels, which the Hub reports in tenths of dB, and without SNR.
+func (c DownstreamChannel) MerDb() float64 {
+	if c.ChannelType == "ofdm" {
+		return float64(c.RxMer) / 10
+	}
+	return float64(c.Snr)
+}
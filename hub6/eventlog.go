@@ -0,0 +1,96 @@
+package exporter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type EventLogEntry struct {
+	// emergency / alert / critical / error / warning / notice / informational / debug
+	Priority string `json:"priority"`
+	// RFC 3339, or empty if the Hub has no time yet
+	Time string `json:"time"`
+	// Message text, followed by attributes, eg: "No Ranging Response received - T3 time-out;CM-MAC=...;"
+	Message string `json:"message"`
+	// DOCSIS event ID
+	EventId uint64 `json:"eventId"`
+}
+
+// GET http://${address}/rest/v1/cablemodem/eventlog
+type EventLog struct {
+	EventLogEntries []EventLogEntry `json:"eventlog"`
+}
+
+// Event is a parsed EventLogEntry.
+type Event struct {
+	Priority string
+	// Zero if unknown
+	Time time.Time
+	// DOCSIS event ID
+	EventId uint64
+	// DOCSIS event code, eg: "R02.0", or the event ID if it can't be mapped to a code
+	Code string
+	// Message text, without attributes
+	Message string
+	// Attributes from the message, eg: CM-MAC
+	Attributes map[string]string
+}
+
+// eventCode maps a DOCSIS event ID to its event code. The ID is the ASCII value of the code
+// letter followed by the 4 digit code number and the 2 digit sub code, eg: 82000200 is R02.0.
+func eventCode(eventId uint64) string {
+	letter := eventId / 1000000
+	if eventId >= 100000000 || letter < 'A' || letter > 'Z' {
+		return strconv.FormatUint(eventId, 10)
+	}
+	return fmt.Sprintf("%c%02d.%d", rune(letter), (eventId/100)%10000, eventId%100)
+}
+
+// Event parses the entry.
+func (e EventLogEntry) Event() Event {
+	event := Event{
+		Priority:   strings.ToLower(e.Priority),
+		EventId:    e.EventId,
+		Code:       eventCode(e.EventId),
+		Attributes: map[string]string{},
+	}
+	if t, err := time.Parse(time.RFC3339, e.Time); err == nil {
+		event.Time = t
+	}
+	var message []string
+	for _, field := range strings.Split(e.Message, ";") {
+		if key, value, ok := strings.Cut(field, "="); ok && isAttributeKey(key) {
+			event.Attributes[key] = value
+			continue
+		}
+		if field = strings.TrimSpace(field); field != "" {
+			message = append(message, field)
+		}
+	}
+	event.Message = strings.Join(message, "; ")
+	return event
+}
+
+// isAttributeKey returns whether key is the key of a message attribute, such as CM-MAC.
+func isAttributeKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, r := range key {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') && r != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// Events parses all entries.
+func (l EventLog) Events() []Event {
+	events := make([]Event, len(l.EventLogEntries))
+	for i, e := range l.EventLogEntries {
+		events[i] = e.Event()
+	}
+	return events
+}
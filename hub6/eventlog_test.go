@@ -0,0 +1,104 @@
+package exporter
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEventCode(t *testing.T) {
+	for _, tc := range []struct {
+		eventId  uint64
+		expected string
+	}{
+		{82000200, "R02.0"},
+		{82000201, "R02.1"},
+		{84000500, "T05.0"},
+		{84020200, "T202.0"},
+		{68010300, "D103.0"},
+		{90000000, "Z00.0"},
+		// Not a letter
+		{64000100, "64000100"},
+		{91000100, "91000100"},
+		{0, "0"},
+		// Too many digits
+		{820002000, "820002000"},
+	} {
+		if got := eventCode(tc.eventId); got != tc.expected {
+			t.Errorf("eventCode(%d): got %q, expected %q", tc.eventId, got, tc.expected)
+		}
+	}
+}
+
+func TestEventLogEntryEvent(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		entry    EventLogEntry
+		expected Event
+	}{
+		{
+			name: "attributes",
+			entry: EventLogEntry{
+				Priority: "Critical",
+				Time:     "2026-01-02T03:04:05Z",
+				Message:  "No Ranging Response received - T3 time-out;CM-MAC=aa:bb:cc:dd:ee:ff;CMTS-MAC=00:11:22:33:44:55;CM-QOS=1.1;CM-VER=3.1;",
+				EventId:  82000200,
+			},
+			expected: Event{
+				Priority: "critical",
+				Time:     time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+				EventId:  82000200,
+				Code:     "R02.0",
+				Message:  "No Ranging Response received - T3 time-out",
+				Attributes: map[string]string{
+					"CM-MAC":   "aa:bb:cc:dd:ee:ff",
+					"CMTS-MAC": "00:11:22:33:44:55",
+					"CM-QOS":   "1.1",
+					"CM-VER":   "3.1",
+				},
+			},
+		},
+		{
+			name: "no time, and a message with multiple parts",
+			entry: EventLogEntry{
+				Priority: "notice",
+				Message:  "DHCP RENEW WARNING - Field invalid in response v4 option; TFTP failed",
+				EventId:  68010300,
+			},
+			expected: Event{
+				Priority:   "notice",
+				EventId:    68010300,
+				Code:       "D103.0",
+				Message:    "DHCP RENEW WARNING - Field invalid in response v4 option; TFTP failed",
+				Attributes: map[string]string{},
+			},
+		},
+		{
+			name: "lower case key=value is part of the message",
+			entry: EventLogEntry{
+				Priority: "warning",
+				Time:     "invalid",
+				Message:  "Reason=unknown;MAC=aa:bb:cc:dd:ee:ff",
+				EventId:  1,
+			},
+			expected: Event{
+				Priority:   "warning",
+				EventId:    1,
+				Code:       "1",
+				Message:    "Reason=unknown",
+				Attributes: map[string]string{"MAC": "aa:bb:cc:dd:ee:ff"},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := tc.entry.Event()
+			if !got.Time.Equal(tc.expected.Time) {
+				t.Errorf("time: got %v, expected %v", got.Time, tc.expected.Time)
+			}
+			got.Time, tc.expected.Time = time.Time{}, time.Time{}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("got %+v, expected %+v", got, tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package exporter
+
+// Wi-Fi connection of a host
+type HostWifi struct {
+	// 2.4g / 5g / 6g
+	Band string `json:"band"`
+	Ssid string `json:"ssid"`
+	// RSSI (dBm)
+	Rssi int64 `json:"rssi"`
+}
+
+type HostIpv4 struct {
+	Address string `json:"address"`
+	// Lease time remaining (seconds)
+	LeaseTimeRemaining uint64 `json:"leaseTimeRemaining"`
+}
+
+type HostConfig struct {
+	Connected  bool   `json:"connected"`
+	DeviceName string `json:"deviceName"`
+	DeviceType string `json:"deviceType"`
+	Hostname   string `json:"hostname"`
+	// ethernet / wifi
+	Interface string `json:"interface"`
+	// Link speed (Mbps)
+	Speed uint64 `json:"speed"`
+	// Only for Wi-Fi hosts
+	Wifi *HostWifi `json:"wifi,omitempty"`
+	Ipv4 HostIpv4  `json:"ipv4"`
+}
+
+type Host struct {
+	MacAddress string     `json:"macAddress"`
+	Config     HostConfig `json:"config"`
+}
+
+type HostsItem struct {
+	Hosts []Host `json:"hosts"`
+}
+
+// GET http://${address}/rest/v1/network/hosts?connectedOnly=true
+// Requires logging in, and is only available in router mode.
+type Hosts struct {
+	HostsItem HostsItem `json:"hosts"`
+}
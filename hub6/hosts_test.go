@@ -0,0 +1,70 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// decodeSample strictly decodes the sample response at path into out, so fields missing from
+// the model are caught.
+func decodeSample(t *testing.T, path string, out any) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(out); err != nil {
+		t.Fatalf("%s: %v", path, err)
+	}
+}
+
+func TestHostsSample(t *testing.T) {
+	var hosts Hosts
+	decodeSample(t, "../sample/hosts", &hosts)
+
+	expected := []Host{
+		{
+			MacAddress: "3C:22:FB:1A:2B:3C",
+			Config: HostConfig{
+				Connected:  true,
+				DeviceName: "laptop",
+				DeviceType: "computer",
+				Hostname:   "laptop",
+				Interface:  "wifi",
+				Speed:      866,
+				Wifi:       &HostWifi{Band: "5g", Ssid: "VM1234567", Rssi: -52},
+				Ipv4:       HostIpv4{Address: "192.168.0.12", LeaseTimeRemaining: 518400},
+			},
+		},
+		{
+			MacAddress: "B8:27:EB:4D:5E:6F",
+			Config: HostConfig{
+				Connected:  true,
+				DeviceName: "raspberrypi",
+				DeviceType: "computer",
+				Hostname:   "raspberrypi",
+				Interface:  "ethernet",
+				Speed:      1000,
+				Ipv4:       HostIpv4{Address: "192.168.0.2", LeaseTimeRemaining: 601200},
+			},
+		},
+		{
+			MacAddress: "A4:77:33:7A:8B:9C",
+			Config: HostConfig{
+				Connected: true,
+				Interface: "wifi",
+				Speed:     72,
+				Wifi:      &HostWifi{Band: "2.4g", Ssid: "VM1234567", Rssi: -78},
+				Ipv4:      HostIpv4{Address: "192.168.0.34", LeaseTimeRemaining: 86400},
+			},
+		},
+	}
+	if !reflect.DeepEqual(hosts.HostsItem.Hosts, expected) {
+		t.Errorf("got %+v, expected %+v", hosts.HostsItem.Hosts, expected)
+	}
+}
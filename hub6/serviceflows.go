@@ -18,10 +18,19 @@ type ServiceFlow struct {
 }
 
 type ServiceFlowItem struct {
-	ServiceFlows []ServiceFlow `json:"serviceFlow"`
+	ServiceFlow ServiceFlow `json:"serviceFlow"`
 }
 
 // GET http://${address}/rest/v1/cablemodem/serviceflows
 type ServiceFlows struct {
-	ServiceFlowItem `json:"serviceFlows"`
+	ServiceFlowItems []ServiceFlowItem `json:"serviceFlows"`
+}
+
+// Flows returns all service flows.
+func (s ServiceFlows) Flows() []ServiceFlow {
+	flows := make([]ServiceFlow, len(s.ServiceFlowItems))
+	for i, item := range s.ServiceFlowItems {
+		flows[i] = item.ServiceFlow
+	}
+	return flows
 }
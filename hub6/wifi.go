@@ -0,0 +1,33 @@
+package exporter
+
+type WifiRadio struct {
+	// Radio ID
+	Id uint64 `json:"id"`
+	// 2.4g / 5g / 6g
+	Band   string `json:"band"`
+	Enable bool   `json:"enable"`
+	// up / down
+	Status string `json:"status"`
+	// 802.11 mode, eg: ax
+	Mode        string `json:"mode"`
+	Channel     uint64 `json:"channel"`
+	AutoChannel bool   `json:"autoChannel"`
+	// Channel bandwidth (MHz)
+	ChannelBandwidth uint64 `json:"channelBandwidth"`
+	// Transmit power (%), not reported by all firmware versions
+	TransmitPower *uint64 `json:"transmitPower,omitempty"`
+	// Number of enabled SSIDs
+	NumberOfSsids uint64 `json:"numberOfSsids"`
+	// Number of connected clients
+	ConnectedClients uint64 `json:"connectedClients"`
+}
+
+type WifiRadiosItem struct {
+	Radios []WifiRadio `json:"radios"`
+}
+
+// GET http://${address}/rest/v1/wifi/radios
+// Requires logging in, and is only available in router mode.
+type WifiRadios struct {
+	WifiRadiosItem WifiRadiosItem `json:"wifi"`
+}
@@ -0,0 +1,47 @@
+package exporter
+
+import (
+	"reflect"
+	"testing"
+)
+
+func uint64Ptr(v uint64) *uint64 {
+	return &v
+}
+
+func TestWifiRadiosSample(t *testing.T) {
+	var radios WifiRadios
+	decodeSample(t, "../sample/radios", &radios)
+
+	expected := []WifiRadio{
+		{
+			Id:               1,
+			Band:             "2.4g",
+			Enable:           true,
+			Status:           "up",
+			Mode:             "ax",
+			Channel:          6,
+			AutoChannel:      true,
+			ChannelBandwidth: 20,
+			TransmitPower:    uint64Ptr(100),
+			NumberOfSsids:    1,
+			ConnectedClients: 1,
+		},
+		{
+			Id:               2,
+			Band:             "5g",
+			Enable:           true,
+			Status:           "up",
+			Mode:             "ax",
+			Channel:          44,
+			AutoChannel:      true,
+			ChannelBandwidth: 80,
+			TransmitPower:    uint64Ptr(75),
+			NumberOfSsids:    1,
+			ConnectedClients: 1,
+		},
+	}
+	if !reflect.DeepEqual(radios.WifiRadiosItem.Radios, expected) {
+		t.Errorf("got %+v, expected %+v", radios.WifiRadiosItem.Radios, expected)
+	}
+}
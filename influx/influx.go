@@ -0,0 +1,234 @@
+package influx
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fornellas/virginmedia_hub6_exporter/exporter"
+)
+
+// https://docs.influxdata.com/influxdb/v2/reference/syntax/line-protocol/
+var (
+	measurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `)
+	keyEscaper         = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+	stringEscaper      = strings.NewReplacer(`"`, `\"`, `\`, `\\`)
+)
+
+// field values must be one of float64, uint64, bool or string.
+type point struct {
+	measurement string
+	tags        map[string]string
+	fields      map[string]any
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (p point) writeTo(b *bytes.Buffer, timestamp time.Time) {
+	b.WriteString(measurementEscaper.Replace(p.measurement))
+	for _, k := range sortedKeys(p.tags) {
+		// Empty tag values are not allowed
+		if p.tags[k] == "" {
+			continue
+		}
+		b.WriteString(",")
+		b.WriteString(keyEscaper.Replace(k))
+		b.WriteString("=")
+		b.WriteString(keyEscaper.Replace(p.tags[k]))
+	}
+	for i, k := range sortedKeys(p.fields) {
+		if i == 0 {
+			b.WriteString(" ")
+		} else {
+			b.WriteString(",")
+		}
+		b.WriteString(keyEscaper.Replace(k))
+		b.WriteString("=")
+		switch v := p.fields[k].(type) {
+		case float64:
+			b.WriteString(strconv.FormatFloat(v, 'f', -1, 64))
+		case uint64:
+			b.WriteString(strconv.FormatUint(v, 10))
+			b.WriteString("u")
+		case bool:
+			b.WriteString(strconv.FormatBool(v))
+		case string:
+			b.WriteString(`"`)
+			b.WriteString(stringEscaper.Replace(v))
+			b.WriteString(`"`)
+		default:
+			panic(fmt.Sprintf("unsupported field type %T", v))
+		}
+	}
+	b.WriteString(" ")
+	b.WriteString(strconv.FormatInt(timestamp.UnixNano(), 10))
+	b.WriteString("\n")
+}
+
+func points(target string, s *exporter.Scrape) []point {
+	var points []point
+
+	if s.Downstream != nil {
+		for _, c := range s.Downstream.DownstreamItem.DownstreamChannels {
+			points = append(points, point{
+				measurement: "virginmedia_hub6_downstream",
+				tags: map[string]string{
+					"target":       target,
+					"channel_id":   strconv.FormatUint(c.ChannelId, 10),
+					"channel_type": c.ChannelType,
+					"modulation":   c.Modulation,
+				},
+				fields: map[string]any{
+					"frequency_hertz":    c.Frequency,
+					"power_dbmv":         c.PowerDbmv(),
+					"snr_db":             c.Snr,
+					"rxmer_db":           c.RxMer,
+					"corrected_errors":   c.CorrectedErrors,
+					"uncorrected_errors": c.UncorrectedErrors,
+					"lock_status":        c.LockStatus,
+				},
+			})
+		}
+	}
+
+	if s.Upstream != nil {
+		for _, c := range s.Upstream.UpstreamItem.Channels {
+			points = append(points, point{
+				measurement: "virginmedia_hub6_upstream",
+				tags: map[string]string{
+					"target":       target,
+					"channel_id":   strconv.FormatUint(c.ChannelId, 10),
+					"channel_type": c.ChannelType,
+					"modulation":   c.Modulation,
+				},
+				fields: map[string]any{
+					"frequency_hertz":  c.Frequency,
+					"power_dbmv":       c.Power,
+					"symbol_rate_ksps": c.SymbolRate,
+					"lock_status":      c.LockStatus,
+					"t1_timeouts":      c.T1Timeout,
+					"t2_timeouts":      c.T2Timeout,
+					"t3_timeouts":      c.T3Timeout,
+					"t4_timeouts":      c.T4Timeout,
+				},
+			})
+		}
+	}
+
+	if s.ServiceFlows != nil {
+		for _, sf := range s.ServiceFlows.Flows() {
+			points = append(points, point{
+				measurement: "virginmedia_hub6_serviceflow",
+				tags: map[string]string{
+					"target":         target,
+					"serviceflow_id": strconv.FormatUint(sf.ServiceFlowId, 10),
+					"direction":      sf.Direction,
+					"schedule_type":  sf.ScheduleType,
+				},
+				fields: map[string]any{
+					"max_traffic_rate_bps":         sf.MaxTrafficRate,
+					"max_traffic_burst_bytes":      sf.MaxTrafficBurst,
+					"min_reserved_rate_bps":        sf.MinReservedRate,
+					"max_concatenated_burst_bytes": sf.MaxConcatenatedBurst,
+				},
+			})
+		}
+	}
+
+	if s.State != nil {
+		cm := s.State.CableModem
+		points = append(points, point{
+			measurement: "virginmedia_hub6_state",
+			tags: map[string]string{
+				"target":         target,
+				"docsis_version": cm.DocsisVersion,
+				"mac_address":    cm.MacAddress,
+				"serial_number":  cm.SerialNumber,
+			},
+			fields: map[string]any{
+				"boot_filename":            cm.BootFilename,
+				"status":                   cm.Status,
+				"uptime_seconds":           cm.UpTime,
+				"access_allowed":           cm.AccessAllowed,
+				"max_cpes":                 cm.MaxCpEs,
+				"baseline_privacy_enabled": cm.BaselinePrivacyEnabled,
+			},
+		})
+	}
+
+	return points
+}
+
+// Marshal encodes s as InfluxDB line protocol, with all points tagged with target.
+func Marshal(target string, s *exporter.Scrape) []byte {
+	var b bytes.Buffer
+	for _, p := range points(target, s) {
+		p.writeTo(&b, s.Time)
+	}
+	return b.Bytes()
+}
+
+// Client writes to the InfluxDB v2 HTTP write API.
+type Client struct {
+	url    string
+	org    string
+	bucket string
+	token  string
+	client *http.Client
+}
+
+// NewClient creates a new Client that writes to bucket on the InfluxDB server at url (eg:
+// http://influxdb:8086). timeout is applied to each HTTP request.
+func NewClient(url, org, bucket, token string, timeout time.Duration) *Client {
+	return &Client{
+		url:    strings.TrimSuffix(url, "/"),
+		org:    org,
+		bucket: bucket,
+		token:  token,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Write sends s to InfluxDB, with all points tagged with target.
+func (c *Client) Write(ctx context.Context, target string, s *exporter.Scrape) error {
+	query := url.Values{}
+	query.Set("org", c.org)
+	query.Set("bucket", c.bucket)
+	query.Set("precision", "ns")
+	writeURL := c.url + "/api/v2/write?" + query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", writeURL, bytes.NewReader(Marshal(target, s)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Token "+c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("unexpected status %d from %s: %s", resp.StatusCode, writeURL, bytes.TrimSpace(msg))
+	}
+	return nil
+}
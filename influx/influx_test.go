@@ -0,0 +1,98 @@
+package influx
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fornellas/virginmedia_hub6_exporter/exporter"
+	hub6 "github.com/fornellas/virginmedia_hub6_exporter/hub6"
+)
+
+func TestPointWriteTo(t *testing.T) {
+	timestamp := time.Unix(1700000000, 123)
+	for _, tc := range []struct {
+		name     string
+		point    point
+		expected string
+	}{
+		{
+			name: "field types",
+			point: point{
+				measurement: "virginmedia_hub6_downstream",
+				tags:        map[string]string{"channel_id": "1", "target": "192.168.0.1"},
+				fields: map[string]any{
+					"power_dbmv": 3.5,
+					"corrected":  uint64(42),
+					"locked":     true,
+					"modulation": "qam_256",
+				},
+			},
+			expected: `virginmedia_hub6_downstream,channel_id=1,target=192.168.0.1 ` +
+				`corrected=42u,locked=true,modulation="qam_256",power_dbmv=3.5 1700000000000000123` + "\n",
+		},
+		{
+			name: "empty tags are skipped",
+			point: point{
+				measurement: "m",
+				tags:        map[string]string{"a": "", "b": "x"},
+				fields:      map[string]any{"v": 1.0},
+			},
+			expected: "m,b=x v=1 1700000000000000123\n",
+		},
+		{
+			name: "escaping",
+			point: point{
+				measurement: "my measurement,1",
+				tags:        map[string]string{"tag key=1": "a,b=c d"},
+				fields:      map[string]any{"field key": `say "hi" \o/`},
+			},
+			expected: `my\ measurement\,1,tag\ key\=1=a\,b\=c\ d field\ key="say \"hi\" \\o/" 1700000000000000123` + "\n",
+		},
+		{
+			name: "floats are not in exponent notation",
+			point: point{
+				measurement: "m",
+				fields:      map[string]any{"big": 1e21, "small": 0.000001},
+			},
+			expected: "m big=1000000000000000000000,small=0.000001 1700000000000000123\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var b bytes.Buffer
+			tc.point.writeTo(&b, timestamp)
+			if got := b.String(); got != tc.expected {
+				t.Errorf("got:\n%s\nexpected:\n%s", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	s := &exporter.Scrape{
+		Time: time.Unix(1700000000, 0),
+		Downstream: &hub6.Downstream{DownstreamItem: hub6.DownstreamItem{DownstreamChannels: []hub6.DownstreamChannel{
+			{ChannelType: "sc_qam", ChannelId: 1, Modulation: "qam_256", Power: 6, Snr: 40, LockStatus: true},
+			// OFDM power is reported in tenths of dBmV
+			{ChannelType: "ofdm", ChannelId: 33, Modulation: "qam_4096", Power: 52, RxMer: 410, LockStatus: true},
+		}}},
+	}
+	lines := strings.Split(strings.TrimSuffix(string(Marshal("192.168.0.1", s)), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", lines)
+	}
+	for _, tc := range []struct {
+		line     string
+		expected []string
+	}{
+		{lines[0], []string{"channel_id=1,", "channel_type=sc_qam,", "power_dbmv=6,", "snr_db=40u,"}},
+		{lines[1], []string{"channel_id=33,", "channel_type=ofdm,", "power_dbmv=5.2,", "rxmer_db=410u,"}},
+	} {
+		for _, expected := range tc.expected {
+			if !strings.Contains(tc.line, expected) {
+				t.Errorf("expected %q in %q", expected, tc.line)
+			}
+		}
+	}
+}
@@ -0,0 +1,284 @@
+package mqtt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// broker is a minimal in-process MQTT 3.1.1 broker, with retained and will messages. Messages
+// are delivered to subscribers with QoS 0.
+type broker struct {
+	listener net.Listener
+
+	mu       sync.Mutex
+	retained map[string][]byte
+	sessions map[string]*session
+}
+
+type session struct {
+	conn    net.Conn
+	writeMu sync.Mutex
+	filters []string
+}
+
+type will struct {
+	topic   string
+	payload []byte
+	retain  bool
+}
+
+func newBroker(t *testing.T) *broker {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &broker{
+		listener: listener,
+		retained: map[string][]byte{},
+		sessions: map[string]*session{},
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go b.serve(conn)
+		}
+	}()
+	t.Cleanup(func() { listener.Close() })
+	return b
+}
+
+// url returns the URL clients connect to.
+func (b *broker) url() string {
+	return "tcp://" + b.listener.Addr().String()
+}
+
+// drop closes the connection of clientId, as if it was lost.
+func (b *broker) drop(clientId string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if s, ok := b.sessions[clientId]; ok {
+		s.conn.Close()
+	}
+}
+
+// retainedMessage returns the retained message of topic, if any.
+func (b *broker) retainedMessage(topic string) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return string(b.retained[topic])
+}
+
+// matches returns whether topic matches filter, with + and # wildcards.
+func matches(filter, topic string) bool {
+	filterLevels := strings.Split(filter, "/")
+	topicLevels := strings.Split(topic, "/")
+	for i, level := range filterLevels {
+		if level == "#" {
+			return true
+		}
+		if i >= len(topicLevels) || (level != "+" && level != topicLevels[i]) {
+			return false
+		}
+	}
+	return len(filterLevels) == len(topicLevels)
+}
+
+func readString(r io.Reader) (string, error) {
+	b, err := readBytes(r)
+	return string(b), err
+}
+
+func readBytes(r io.Reader) ([]byte, error) {
+	var n uint16
+	if err := binary.Read(r, binary.BigEndian, &n); err != nil {
+		return nil, err
+	}
+	b := make([]byte, n)
+	_, err := io.ReadFull(r, b)
+	return b, err
+}
+
+func appendString(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
+
+func (s *session) write(header byte, body []byte) error {
+	packet := []byte{header}
+	n := len(body)
+	for {
+		digit := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			digit |= 0x80
+		}
+		packet = append(packet, digit)
+		if n == 0 {
+			break
+		}
+	}
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	_, err := s.conn.Write(append(packet, body...))
+	return err
+}
+
+func (s *session) deliver(topic string, payload []byte, retain bool) {
+	header := byte(0x30)
+	if retain {
+		header |= 0x01
+	}
+	s.write(header, append(appendString(nil, topic), payload...))
+}
+
+// publish retains the message if requested, and delivers it to matching subscribers.
+func (b *broker) publish(topic string, payload []byte, retain bool) {
+	b.mu.Lock()
+	if retain {
+		if len(payload) == 0 {
+			delete(b.retained, topic)
+		} else {
+			b.retained[topic] = payload
+		}
+	}
+	var subscribers []*session
+	for _, s := range b.sessions {
+		for _, filter := range s.filters {
+			if matches(filter, topic) {
+				subscribers = append(subscribers, s)
+				break
+			}
+		}
+	}
+	b.mu.Unlock()
+	for _, s := range subscribers {
+		s.deliver(topic, payload, false)
+	}
+}
+
+func (b *broker) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	s := &session{conn: conn}
+	var clientId string
+	var w *will
+	for {
+		header, err := r.ReadByte()
+		if err != nil {
+			break
+		}
+		var length, multiplier int = 0, 1
+		for {
+			digit, err := r.ReadByte()
+			if err != nil {
+				break
+			}
+			length += int(digit&0x7f) * multiplier
+			multiplier *= 128
+			if digit&0x80 == 0 {
+				break
+			}
+		}
+		body := make([]byte, length)
+		if _, err := io.ReadFull(r, body); err != nil {
+			break
+		}
+		br := strings.NewReader(string(body))
+
+		switch header >> 4 {
+		case 1: // CONNECT
+			if _, err := readString(br); err != nil {
+				return
+			}
+			var level, flags byte
+			var keepAlive uint16
+			binary.Read(br, binary.BigEndian, &level)
+			binary.Read(br, binary.BigEndian, &flags)
+			binary.Read(br, binary.BigEndian, &keepAlive)
+			clientId, _ = readString(br)
+			if flags&0x04 != 0 {
+				topic, _ := readString(br)
+				payload, _ := readBytes(br)
+				w = &will{topic: topic, payload: payload, retain: flags&0x20 != 0}
+			}
+			b.mu.Lock()
+			b.sessions[clientId] = s
+			b.mu.Unlock()
+			s.write(0x20, []byte{0, 0})
+		case 3: // PUBLISH
+			topic, err := readString(br)
+			if err != nil {
+				return
+			}
+			if qos := (header >> 1) & 0x03; qos > 0 {
+				var id uint16
+				binary.Read(br, binary.BigEndian, &id)
+				s.write(0x40, binary.BigEndian.AppendUint16(nil, id))
+			}
+			payload, _ := io.ReadAll(br)
+			b.publish(topic, payload, header&0x01 != 0)
+		case 8: // SUBSCRIBE
+			var id uint16
+			binary.Read(br, binary.BigEndian, &id)
+			var filters []string
+			granted := binary.BigEndian.AppendUint16(nil, id)
+			for br.Len() > 0 {
+				filter, err := readString(br)
+				if err != nil {
+					return
+				}
+				br.ReadByte()
+				filters = append(filters, filter)
+				granted = append(granted, 0)
+			}
+			b.mu.Lock()
+			s.filters = append(s.filters, filters...)
+			retained := map[string][]byte{}
+			for topic, payload := range b.retained {
+				for _, filter := range filters {
+					if matches(filter, topic) {
+						retained[topic] = payload
+					}
+				}
+			}
+			b.mu.Unlock()
+			s.write(0x90, granted)
+			for topic, payload := range retained {
+				s.deliver(topic, payload, true)
+			}
+		case 10: // UNSUBSCRIBE
+			s.write(0xb0, body[:2])
+		case 12: // PINGREQ
+			s.write(0xd0, nil)
+		case 14: // DISCONNECT
+			// The will is discarded on a clean disconnect
+			w = nil
+			b.removeSession(clientId, s)
+			return
+		default:
+			// Unsupported packet
+			return
+		}
+	}
+	b.removeSession(clientId, s)
+	if w != nil {
+		b.publish(w.topic, w.payload, w.retain)
+	}
+}
+
+func (b *broker) removeSession(clientId string, s *session) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.sessions[clientId] == s {
+		delete(b.sessions, clientId)
+	}
+}
@@ -0,0 +1,249 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/fornellas/virginmedia_hub6_exporter/exporter"
+	hub6 "github.com/fornellas/virginmedia_hub6_exporter/hub6"
+)
+
+const publishTimeout = 10 * time.Second
+
+// Home Assistant MQTT device discovery.
+// https://www.home-assistant.io/integrations/mqtt/#mqtt-discovery
+type device struct {
+	Identifiers      []string    `json:"identifiers"`
+	Connections      [][2]string `json:"connections,omitempty"`
+	Name             string      `json:"name"`
+	Manufacturer     string      `json:"manufacturer"`
+	Model            string      `json:"model"`
+	SerialNumber     string      `json:"serial_number,omitempty"`
+	HwVersion        string      `json:"hw_version,omitempty"`
+	ConfigurationUrl string      `json:"configuration_url,omitempty"`
+}
+
+type discoveryConfig struct {
+	Name              string  `json:"name"`
+	UniqueId          string  `json:"unique_id"`
+	StateTopic        string  `json:"state_topic"`
+	ValueTemplate     string  `json:"value_template"`
+	AvailabilityTopic string  `json:"availability_topic"`
+	UnitOfMeasurement string  `json:"unit_of_measurement,omitempty"`
+	DeviceClass       string  `json:"device_class,omitempty"`
+	StateClass        string  `json:"state_class,omitempty"`
+	EntityCategory    string  `json:"entity_category,omitempty"`
+	Device            *device `json:"device"`
+}
+
+type sensor struct {
+	// sensor or binary_sensor
+	component      string
+	key            string
+	name           string
+	unit           string
+	deviceClass    string
+	stateClass     string
+	entityCategory string
+}
+
+// Publisher publishes Hub data to MQTT, along with Home Assistant discovery messages, so the
+// Hub shows up as a device with sensors.
+type Publisher struct {
+	client          paho.Client
+	target          string
+	topicPrefix     string
+	discoveryPrefix string
+	// Keys of sensors which had their discovery config published
+	discovered map[string]bool
+}
+
+// NewPublisher creates a new Publisher for the Hub at target. State is published under
+// topicPrefix, and discovery messages under discoveryPrefix (Home Assistant's default is
+// "homeassistant").
+func NewPublisher(client paho.Client, target, topicPrefix, discoveryPrefix string) *Publisher {
+	return &Publisher{
+		client:          client,
+		target:          target,
+		topicPrefix:     topicPrefix,
+		discoveryPrefix: discoveryPrefix,
+		discovered:      map[string]bool{},
+	}
+}
+
+// nodeId returns target in a form which is valid as a topic level and Home Assistant node ID.
+func nodeId(target string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' || r == '-' {
+			return r
+		}
+		return '_'
+	}, target)
+}
+
+func (p *Publisher) stateTopic() string {
+	return fmt.Sprintf("%s/%s/state", p.topicPrefix, nodeId(p.target))
+}
+
+// AvailabilityTopic returns the topic where "online" or "offline" is published, which should
+// also be used for the client's will message.
+func AvailabilityTopic(topicPrefix, target string) string {
+	return fmt.Sprintf("%s/%s/availability", topicPrefix, nodeId(target))
+}
+
+// NewClientOptions returns the options of a client of broker, with a retained will message
+// marking the Hub at target as offline, which the broker publishes if the connection is lost.
+func NewClientOptions(broker, topicPrefix, target string) *paho.ClientOptions {
+	return paho.NewClientOptions().
+		AddBroker(broker).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetWill(AvailabilityTopic(topicPrefix, target), "offline", 1, true)
+}
+
+func (p *Publisher) publish(topic string, retained bool, payload any) error {
+	token := p.client.Publish(topic, 1, retained, payload)
+	if !token.WaitTimeout(publishTimeout) {
+		return fmt.Errorf("timeout publishing to %s", topic)
+	}
+	if err := token.Error(); err != nil {
+		return fmt.Errorf("failed to publish to %s: %w", topic, err)
+	}
+	return nil
+}
+
+func (p *Publisher) publishDiscovery(cm hub6.CableModem, sensors []sensor) error {
+	uniqueIdPrefix := "virginmedia_hub6_" + strings.ToLower(strings.ReplaceAll(cm.MacAddress, ":", ""))
+	dev := &device{
+		Identifiers:      []string{uniqueIdPrefix},
+		Connections:      [][2]string{{"mac", strings.ToLower(cm.MacAddress)}},
+		Name:             "Virgin Media Hub 6",
+		Manufacturer:     "Virgin Media",
+		Model:            "Hub 6",
+		SerialNumber:     cm.SerialNumber,
+		HwVersion:        "DOCSIS " + cm.DocsisVersion,
+		ConfigurationUrl: "http://" + p.target,
+	}
+	for _, s := range sensors {
+		if p.discovered[s.key] {
+			continue
+		}
+		valueTemplate := fmt.Sprintf("{{ value_json.%s }}", s.key)
+		if s.component == "binary_sensor" {
+			valueTemplate = fmt.Sprintf("{{ 'ON' if value_json.%s else 'OFF' }}", s.key)
+		}
+		config, err := json.Marshal(discoveryConfig{
+			Name:              s.name,
+			UniqueId:          uniqueIdPrefix + "_" + s.key,
+			StateTopic:        p.stateTopic(),
+			ValueTemplate:     valueTemplate,
+			AvailabilityTopic: AvailabilityTopic(p.topicPrefix, p.target),
+			UnitOfMeasurement: s.unit,
+			DeviceClass:       s.deviceClass,
+			StateClass:        s.stateClass,
+			EntityCategory:    s.entityCategory,
+			Device:            dev,
+		})
+		if err != nil {
+			return err
+		}
+		topic := fmt.Sprintf("%s/%s/%s/%s/config", p.discoveryPrefix, s.component, nodeId(p.target), s.key)
+		if err := p.publish(topic, true, config); err != nil {
+			return err
+		}
+		p.discovered[s.key] = true
+	}
+	return nil
+}
+
+func stateSensors(s *exporter.Scrape, state map[string]any) []sensor {
+	cm := s.State.CableModem
+	state["status"] = cm.Status
+	state["uptime"] = cm.UpTime
+	state["access_allowed"] = cm.AccessAllowed
+	return []sensor{
+		{component: "sensor", key: "status", name: "Status"},
+		{component: "sensor", key: "uptime", name: "Uptime", unit: "s", deviceClass: "duration", stateClass: "measurement", entityCategory: "diagnostic"},
+		{component: "binary_sensor", key: "access_allowed", name: "Network access", entityCategory: "diagnostic"},
+	}
+}
+
+func downstreamSensors(s *exporter.Scrape, state map[string]any) []sensor {
+	if s.Downstream == nil {
+		return nil
+	}
+	var sensors []sensor
+	for _, c := range s.Downstream.DownstreamItem.DownstreamChannels {
+		prefix := fmt.Sprintf("downstream_%d_", c.ChannelId)
+		name := fmt.Sprintf("Downstream %d ", c.ChannelId)
+		state[prefix+"power"] = c.PowerDbmv()
+		state[prefix+"snr"] = c.MerDb()
+		state[prefix+"corrected_errors"] = c.CorrectedErrors
+		state[prefix+"uncorrected_errors"] = c.UncorrectedErrors
+		sensors = append(sensors,
+			sensor{component: "sensor", key: prefix + "power", name: name + "power", unit: "dBmV", stateClass: "measurement", entityCategory: "diagnostic"},
+			sensor{component: "sensor", key: prefix + "snr", name: name + "SNR", unit: "dB", stateClass: "measurement", entityCategory: "diagnostic"},
+			sensor{component: "sensor", key: prefix + "corrected_errors", name: name + "corrected errors", stateClass: "total_increasing", entityCategory: "diagnostic"},
+			sensor{component: "sensor", key: prefix + "uncorrected_errors", name: name + "uncorrected errors", stateClass: "total_increasing", entityCategory: "diagnostic"},
+		)
+	}
+	return sensors
+}
+
+func upstreamSensors(s *exporter.Scrape, state map[string]any) []sensor {
+	if s.Upstream == nil {
+		return nil
+	}
+	var sensors []sensor
+	for _, c := range s.Upstream.UpstreamItem.Channels {
+		prefix := fmt.Sprintf("upstream_%d_", c.ChannelId)
+		name := fmt.Sprintf("Upstream %d ", c.ChannelId)
+		state[prefix+"power"] = c.Power
+		state[prefix+"t3_timeouts"] = c.T3Timeout
+		state[prefix+"t4_timeouts"] = c.T4Timeout
+		sensors = append(sensors,
+			sensor{component: "sensor", key: prefix + "power", name: name + "power", unit: "dBmV", stateClass: "measurement", entityCategory: "diagnostic"},
+			sensor{component: "sensor", key: prefix + "t3_timeouts", name: name + "T3 timeouts", stateClass: "total_increasing", entityCategory: "diagnostic"},
+			sensor{component: "sensor", key: prefix + "t4_timeouts", name: name + "T4 timeouts", stateClass: "total_increasing", entityCategory: "diagnostic"},
+		)
+	}
+	return sensors
+}
+
+// Publish publishes the discovery config of any new sensor, the state of all sensors and the
+// availability. The device identification comes from the state endpoint, so if it failed,
+// the device is marked as offline.
+func (p *Publisher) Publish(s *exporter.Scrape) error {
+	availabilityTopic := AvailabilityTopic(p.topicPrefix, p.target)
+
+	if s.State == nil {
+		if err := p.publish(availabilityTopic, true, "offline"); err != nil {
+			return err
+		}
+		return fmt.Errorf("state: %w", s.StateErr)
+	}
+
+	state := map[string]any{}
+	var sensors []sensor
+	sensors = append(sensors, stateSensors(s, state)...)
+	sensors = append(sensors, downstreamSensors(s, state)...)
+	sensors = append(sensors, upstreamSensors(s, state)...)
+
+	if err := p.publishDiscovery(s.State.CableModem, sensors); err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if err := p.publish(p.stateTopic(), true, payload); err != nil {
+		return err
+	}
+
+	return p.publish(availabilityTopic, true, "online")
+}
@@ -0,0 +1,243 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/fornellas/virginmedia_hub6_exporter/exporter"
+	hub6 "github.com/fornellas/virginmedia_hub6_exporter/hub6"
+)
+
+type doneToken struct{}
+
+func (doneToken) Wait() bool                     { return true }
+func (doneToken) WaitTimeout(time.Duration) bool { return true }
+func (doneToken) Done() <-chan struct{}          { ch := make(chan struct{}); close(ch); return ch }
+func (doneToken) Error() error                   { return nil }
+
+type message struct {
+	retained bool
+	payload  string
+}
+
+// fakeClient records published messages; other methods are not implemented.
+type fakeClient struct {
+	paho.Client
+
+	mu       sync.Mutex
+	messages map[string][]message
+}
+
+func (c *fakeClient) Publish(topic string, qos byte, retained bool, payload any) paho.Token {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var p string
+	switch v := payload.(type) {
+	case string:
+		p = v
+	case []byte:
+		p = string(v)
+	}
+	c.messages[topic] = append(c.messages[topic], message{retained: retained, payload: p})
+	return doneToken{}
+}
+
+func newScrape() *exporter.Scrape {
+	return &exporter.Scrape{
+		State: &hub6.State{CableModem: hub6.CableModem{
+			MacAddress:    "8C:9A:8F:57:77:30",
+			SerialNumber:  "YBES51534445",
+			DocsisVersion: "3.1",
+			UpTime:        238208,
+			AccessAllowed: true,
+			Status:        "operational",
+		}},
+		Downstream: &hub6.Downstream{DownstreamItem: hub6.DownstreamItem{DownstreamChannels: []hub6.DownstreamChannel{
+			{ChannelType: "sc_qam", ChannelId: 1, Power: 6, Snr: 40, CorrectedErrors: 19},
+			{ChannelType: "ofdm", ChannelId: 33, Power: 52, RxMer: 410},
+		}}},
+		Upstream: &hub6.Upstream{UpstreamItem: hub6.UpstreamItem{Channels: []hub6.UpstreamChannel{
+			{ChannelId: 2, Power: 44.5, T3Timeout: 3},
+		}}},
+	}
+}
+
+func TestPublisherPublish(t *testing.T) {
+	client := &fakeClient{messages: map[string][]message{}}
+	p := NewPublisher(client, "192.168.0.1", "vm", "homeassistant")
+
+	if err := p.Publish(newScrape()); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+
+	availability := client.messages["vm/192_168_0_1/availability"]
+	if len(availability) != 1 || availability[0] != (message{retained: true, payload: "online"}) {
+		t.Errorf("unexpected availability messages: %v", availability)
+	}
+
+	states := client.messages["vm/192_168_0_1/state"]
+	if len(states) != 1 {
+		t.Fatalf("expected 1 state message, got %d", len(states))
+	}
+	var state map[string]any
+	if err := json.Unmarshal([]byte(states[0].payload), &state); err != nil {
+		t.Fatalf("invalid state: %v", err)
+	}
+	for key, expected := range map[string]any{
+		"status":                        "operational",
+		"uptime":                        238208.0,
+		"access_allowed":                true,
+		"downstream_1_power":            6.0,
+		"downstream_1_snr":              40.0,
+		"downstream_1_corrected_errors": 19.0,
+		// OFDM power and RxMER are reported in tenths
+		"downstream_33_power":    5.2,
+		"downstream_33_snr":      41.0,
+		"upstream_2_power":       44.5,
+		"upstream_2_t3_timeouts": 3.0,
+	} {
+		if state[key] != expected {
+			t.Errorf("state %s: got %v, expected %v", key, state[key], expected)
+		}
+	}
+
+	topic := "homeassistant/binary_sensor/192_168_0_1/access_allowed/config"
+	configs := client.messages[topic]
+	if len(configs) != 1 || !configs[0].retained {
+		t.Fatalf("unexpected %s messages: %v", topic, configs)
+	}
+	var config discoveryConfig
+	if err := json.Unmarshal([]byte(configs[0].payload), &config); err != nil {
+		t.Fatalf("invalid discovery config: %v", err)
+	}
+	if config.UniqueId != "virginmedia_hub6_8c9a8f577730_access_allowed" {
+		t.Errorf("unexpected unique_id: %s", config.UniqueId)
+	}
+	if config.ValueTemplate != "{{ 'ON' if value_json.access_allowed else 'OFF' }}" {
+		t.Errorf("unexpected value_template: %s", config.ValueTemplate)
+	}
+	if config.StateTopic != "vm/192_168_0_1/state" || config.AvailabilityTopic != "vm/192_168_0_1/availability" {
+		t.Errorf("unexpected topics: %s %s", config.StateTopic, config.AvailabilityTopic)
+	}
+
+	// Discovery is only published for new sensors
+	if err := p.Publish(newScrape()); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	if n := len(client.messages[topic]); n != 1 {
+		t.Errorf("expected discovery to be published once, got %d", n)
+	}
+	if n := len(client.messages["vm/192_168_0_1/state"]); n != 2 {
+		t.Errorf("expected 2 state messages, got %d", n)
+	}
+}
+
+func TestPublisherPublishOffline(t *testing.T) {
+	client := &fakeClient{messages: map[string][]message{}}
+	p := NewPublisher(client, "192.168.0.1", "vm", "homeassistant")
+
+	stateErr := errors.New("connection refused")
+	if err := p.Publish(&exporter.Scrape{StateErr: stateErr}); !errors.Is(err, stateErr) {
+		t.Errorf("expected state error, got %v", err)
+	}
+	if len(client.messages) != 1 {
+		t.Errorf("expected only availability to be published, got %v", client.messages)
+	}
+	availability := client.messages["vm/192_168_0_1/availability"]
+	if len(availability) != 1 || availability[0] != (message{retained: true, payload: "offline"}) {
+		t.Errorf("unexpected availability messages: %v", availability)
+	}
+}
+
+// subscribe connects a client to b, which sends the messages of topics matching filter to the
+// returned channel.
+func subscribe(t *testing.T, b *broker, clientId, filter string) <-chan paho.Message {
+	t.Helper()
+	messages := make(chan paho.Message, 100)
+	client := paho.NewClient(paho.NewClientOptions().AddBroker(b.url()).SetClientID(clientId))
+	if token := client.Connect(); !token.WaitTimeout(5*time.Second) || token.Error() != nil {
+		t.Fatalf("failed to connect: %v", token.Error())
+	}
+	t.Cleanup(func() { client.Disconnect(0) })
+	token := client.Subscribe(filter, 0, func(_ paho.Client, m paho.Message) { messages <- m })
+	if !token.WaitTimeout(5*time.Second) || token.Error() != nil {
+		t.Fatalf("failed to subscribe: %v", token.Error())
+	}
+	return messages
+}
+
+// receive returns the next message of topic from messages.
+func receive(t *testing.T, messages <-chan paho.Message, topic string) paho.Message {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case m := <-messages:
+			if m.Topic() == topic {
+				return m
+			}
+		case <-timeout:
+			t.Fatalf("timeout waiting for a message on %s", topic)
+		}
+	}
+}
+
+func TestPublisherBroker(t *testing.T) {
+	b := newBroker(t)
+
+	opts := NewClientOptions(b.url(), "vm", "192.168.0.1").
+		SetClientID("exporter").
+		SetAutoReconnect(false)
+	client := paho.NewClient(opts)
+	if token := client.Connect(); !token.WaitTimeout(5*time.Second) || token.Error() != nil {
+		t.Fatalf("failed to connect: %v", token.Error())
+	}
+	defer client.Disconnect(0)
+
+	p := NewPublisher(client, "192.168.0.1", "vm", "homeassistant")
+	if err := p.Publish(newScrape()); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+
+	// Discovery config, state and availability are retained, so Home Assistant gets them when it
+	// subscribes later
+	discovery := subscribe(t, b, "homeassistant", "homeassistant/#")
+	m := receive(t, discovery, "homeassistant/sensor/192_168_0_1/downstream_33_snr/config")
+	if !m.Retained() {
+		t.Error("expected discovery config to be retained")
+	}
+	var config discoveryConfig
+	if err := json.Unmarshal(m.Payload(), &config); err != nil {
+		t.Fatalf("invalid discovery config: %v", err)
+	}
+	if config.StateTopic != "vm/192_168_0_1/state" || config.ValueTemplate != "{{ value_json.downstream_33_snr }}" {
+		t.Errorf("unexpected discovery config: %+v", config)
+	}
+
+	messages := subscribe(t, b, "subscriber", "vm/#")
+	m = receive(t, messages, "vm/192_168_0_1/state")
+	var state map[string]any
+	if err := json.Unmarshal(m.Payload(), &state); err != nil {
+		t.Fatalf("invalid state: %v", err)
+	}
+	if state["downstream_33_snr"] != 41.0 {
+		t.Errorf("unexpected state: %v", state)
+	}
+	if m := receive(t, messages, "vm/192_168_0_1/availability"); string(m.Payload()) != "online" {
+		t.Errorf("got availability %s, expected online", m.Payload())
+	}
+
+	// When the connection is lost, the broker publishes the will
+	b.drop("exporter")
+	if m := receive(t, messages, "vm/192_168_0_1/availability"); string(m.Payload()) != "offline" {
+		t.Errorf("got availability %s, expected offline", m.Payload())
+	}
+	if b.retainedMessage("vm/192_168_0_1/availability") != "offline" {
+		t.Error("expected the will to be retained")
+	}
+}
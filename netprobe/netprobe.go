@@ -0,0 +1,238 @@
+package netprobe
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// connectResult is the result of connecting to an address.
+type connectResult struct {
+	latency time.Duration
+	err     error
+}
+
+// downloadResult is the result of downloading from a URL.
+type downloadResult struct {
+	bytes    int64
+	duration time.Duration
+	err      error
+}
+
+// Prober periodically measures TCP connect latency and HTTP download throughput against
+// configured endpoints, and exports the latest results as metrics.
+type Prober struct {
+	connectAddresses []string
+	downloadURLs     []string
+	timeout          time.Duration
+	maxBytes         int64
+	client           *http.Client
+
+	mu        sync.Mutex
+	lastTime  time.Time
+	connects  map[string]connectResult
+	downloads map[string]downloadResult
+
+	descLastRun            *prometheus.Desc
+	descConnectSuccess     *prometheus.Desc
+	descConnectLatency     *prometheus.Desc
+	descDownloadSuccess    *prometheus.Desc
+	descDownloadBytes      *prometheus.Desc
+	descDownloadDuration   *prometheus.Desc
+	descDownloadThroughput *prometheus.Desc
+}
+
+// NewProber creates a new Prober, which connects to each of connectAddresses (host:port), and
+// downloads from each of downloadURLs, reading up to maxBytes. timeout is applied to each
+// connection and download.
+func NewProber(connectAddresses, downloadURLs []string, timeout time.Duration, maxBytes int64) *Prober {
+	labels := []string{"endpoint"}
+	return &Prober{
+		connectAddresses: connectAddresses,
+		downloadURLs:     downloadURLs,
+		timeout:          timeout,
+		maxBytes:         maxBytes,
+		client: &http.Client{
+			// Each download is measured over a new connection
+			Transport: &http.Transport{
+				Proxy:              http.ProxyFromEnvironment,
+				DisableKeepAlives:  true,
+				DisableCompression: true,
+			},
+		},
+
+		descLastRun: prometheus.NewDesc(
+			"virginmedia_hub6_probe_last_run_timestamp_seconds",
+			"Unix timestamp of when the latest network probes started",
+			nil, nil,
+		),
+		descConnectSuccess: prometheus.NewDesc(
+			"virginmedia_hub6_probe_connect_success",
+			"Whether the TCP connection to the endpoint succeeded (1 = success, 0 = failure)",
+			labels, nil,
+		),
+		descConnectLatency: prometheus.NewDesc(
+			"virginmedia_hub6_probe_connect_latency_seconds",
+			"Time taken to establish a TCP connection to the endpoint in seconds",
+			labels, nil,
+		),
+		descDownloadSuccess: prometheus.NewDesc(
+			"virginmedia_hub6_probe_download_success",
+			"Whether the HTTP download from the endpoint succeeded (1 = success, 0 = failure)",
+			labels, nil,
+		),
+		descDownloadBytes: prometheus.NewDesc(
+			"virginmedia_hub6_probe_download_bytes",
+			"Number of bytes downloaded from the endpoint",
+			labels, nil,
+		),
+		descDownloadDuration: prometheus.NewDesc(
+			"virginmedia_hub6_probe_download_duration_seconds",
+			"Time taken to download the response body from the endpoint in seconds",
+			labels, nil,
+		),
+		descDownloadThroughput: prometheus.NewDesc(
+			"virginmedia_hub6_probe_download_throughput_bps",
+			"HTTP download throughput from the endpoint in bits per second",
+			labels, nil,
+		),
+	}
+}
+
+func (p *Prober) connect(ctx context.Context, address string) connectResult {
+	dialer := &net.Dialer{Timeout: p.timeout}
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return connectResult{err: err}
+	}
+	latency := time.Since(start)
+	conn.Close()
+	return connectResult{latency: latency}
+}
+
+// download measures the time taken to read the response body, so it excludes connection setup
+// and server response time.
+func (p *Prober) download(ctx context.Context, url string) downloadResult {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return downloadResult{err: err}
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return downloadResult{err: err}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return downloadResult{err: fmt.Errorf("unexpected status %d from %s", resp.StatusCode, url)}
+	}
+
+	start := time.Now()
+	n, err := io.Copy(io.Discard, io.LimitReader(resp.Body, p.maxBytes))
+	result := downloadResult{bytes: n, duration: time.Since(start)}
+	// Reaching the timeout is fine, as long as something was downloaded
+	if err != nil && !(ctx.Err() != nil && n > 0) {
+		result.err = err
+	}
+	return result
+}
+
+// Run probes all endpoints once. Downloads run one at a time, so they don't compete for
+// bandwidth.
+func (p *Prober) Run(ctx context.Context) {
+	now := time.Now()
+	connects := map[string]connectResult{}
+	for _, address := range p.connectAddresses {
+		result := p.connect(ctx, address)
+		if result.err != nil {
+			slog.Warn("Connect probe failed", "endpoint", address, "err", result.err)
+		}
+		connects[address] = result
+	}
+	downloads := map[string]downloadResult{}
+	for _, url := range p.downloadURLs {
+		result := p.download(ctx, url)
+		if result.err != nil {
+			slog.Warn("Download probe failed", "endpoint", url, "err", result.err)
+		}
+		downloads[url] = result
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.lastTime = now
+	p.connects = connects
+	p.downloads = downloads
+}
+
+// Start runs the probes in the background at every interval, until ctx is done.
+func (p *Prober) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			p.Run(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Describe sends the descriptors of each metric over the provided channel.
+func (p *Prober) Describe(ch chan<- *prometheus.Desc) {
+	ch <- p.descLastRun
+	ch <- p.descConnectSuccess
+	ch <- p.descConnectLatency
+	ch <- p.descDownloadSuccess
+	ch <- p.descDownloadBytes
+	ch <- p.descDownloadDuration
+	ch <- p.descDownloadThroughput
+}
+
+// Collect exports the results of the latest probes, if any.
+func (p *Prober) Collect(ch chan<- prometheus.Metric) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.lastTime.IsZero() {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(p.descLastRun, prometheus.GaugeValue, float64(p.lastTime.UnixNano())/1e9)
+
+	for address, r := range p.connects {
+		success := 0.0
+		if r.err == nil {
+			success = 1.0
+			ch <- prometheus.MustNewConstMetric(p.descConnectLatency, prometheus.GaugeValue, r.latency.Seconds(), address)
+		}
+		ch <- prometheus.MustNewConstMetric(p.descConnectSuccess, prometheus.GaugeValue, success, address)
+	}
+
+	for url, r := range p.downloads {
+		success := 0.0
+		if r.err == nil {
+			success = 1.0
+			ch <- prometheus.MustNewConstMetric(p.descDownloadBytes, prometheus.GaugeValue, float64(r.bytes), url)
+			ch <- prometheus.MustNewConstMetric(p.descDownloadDuration, prometheus.GaugeValue, r.duration.Seconds(), url)
+			if r.duration > 0 {
+				ch <- prometheus.MustNewConstMetric(
+					p.descDownloadThroughput, prometheus.GaugeValue, float64(r.bytes*8)/r.duration.Seconds(), url,
+				)
+			}
+		}
+		ch <- prometheus.MustNewConstMetric(p.descDownloadSuccess, prometheus.GaugeValue, success, url)
+	}
+}
@@ -0,0 +1,118 @@
+package netprobe
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// gather returns the value of each metric collected by p, by name and endpoint.
+func gather(t *testing.T, p *Prober) map[string]float64 {
+	t.Helper()
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(p)
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := map[string]float64{}
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			key := family.GetName()
+			for _, label := range m.GetLabel() {
+				key += "{" + label.GetValue() + "}"
+			}
+			values[key] = m.GetGauge().GetValue()
+		}
+	}
+	return values
+}
+
+func TestProberConnect(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	open := listener.Addr().String()
+
+	// A port nothing is listening on
+	closedListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed := closedListener.Addr().String()
+	closedListener.Close()
+
+	p := NewProber([]string{open, closed}, nil, 5*time.Second, 1024)
+	if values := gather(t, p); len(values) != 0 {
+		t.Errorf("expected no metrics before the first run, got %v", values)
+	}
+	p.Run(context.Background())
+	values := gather(t, p)
+
+	if v := values["virginmedia_hub6_probe_connect_success{"+open+"}"]; v != 1 {
+		t.Errorf("%s: expected success, got %v", open, v)
+	}
+	if _, ok := values["virginmedia_hub6_probe_connect_latency_seconds{"+open+"}"]; !ok {
+		t.Errorf("%s: expected latency", open)
+	}
+	if v, ok := values["virginmedia_hub6_probe_connect_success{"+closed+"}"]; !ok || v != 0 {
+		t.Errorf("%s: expected failure, got %v", closed, v)
+	}
+	if _, ok := values["virginmedia_hub6_probe_connect_latency_seconds{"+closed+"}"]; ok {
+		t.Errorf("%s: expected no latency", closed)
+	}
+	if values["virginmedia_hub6_probe_last_run_timestamp_seconds"] == 0 {
+		t.Error("expected last run timestamp")
+	}
+}
+
+func TestProberDownload(t *testing.T) {
+	body := strings.Repeat("x", 4096)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	for _, tc := range []struct {
+		name     string
+		path     string
+		maxBytes int64
+		success  bool
+		bytes    float64
+	}{
+		{"success", "/file", 1 << 20, true, 4096},
+		{"limited to maxBytes", "/file", 1000, true, 1000},
+		{"non-2xx", "/missing", 1 << 20, false, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			url := server.URL + tc.path
+			p := NewProber(nil, []string{url}, 5*time.Second, tc.maxBytes)
+			p.Run(context.Background())
+			values := gather(t, p)
+
+			success := values["virginmedia_hub6_probe_download_success{"+url+"}"]
+			if (success == 1) != tc.success {
+				t.Fatalf("got success %v, expected %v", success, tc.success)
+			}
+			bytes, ok := values["virginmedia_hub6_probe_download_bytes{"+url+"}"]
+			if ok != tc.success || bytes != tc.bytes {
+				t.Errorf("got bytes %v (%v), expected %v", bytes, ok, tc.bytes)
+			}
+			if _, ok := values["virginmedia_hub6_probe_download_duration_seconds{"+url+"}"]; ok != tc.success {
+				t.Errorf("got duration %v, expected %v", ok, tc.success)
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package otlp
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/resource"
+
+	hub6 "github.com/fornellas/virginmedia_hub6_exporter/hub6"
+)
+
+const scopeName = "github.com/fornellas/virginmedia_hub6_exporter"
+
+// Producer is an sdkmetric.Producer that exposes the metrics from a Prometheus Gatherer as OTLP
+// metrics, with the same names, and labels as attributes. It is registered with a reader with
+// sdkmetric.WithProducer.
+type Producer struct {
+	gatherer  prometheus.Gatherer
+	startTime time.Time
+}
+
+// NewProducer creates a new Producer for the metrics from g.
+func NewProducer(g prometheus.Gatherer) *Producer {
+	return &Producer{
+		gatherer:  g,
+		startTime: time.Now(),
+	}
+}
+
+func attributes(m *dto.Metric) attribute.Set {
+	kvs := make([]attribute.KeyValue, 0, len(m.GetLabel()))
+	for _, lp := range m.GetLabel() {
+		kvs = append(kvs, attribute.String(lp.GetName(), lp.GetValue()))
+	}
+	return attribute.NewSet(kvs...)
+}
+
+// histogramDataPoint converts the cumulative buckets of a Prometheus histogram to the
+// per bucket counts of OTLP.
+func histogramDataPoint(m *dto.Metric, startTime, now time.Time) metricdata.HistogramDataPoint[float64] {
+	h := m.GetHistogram()
+	dp := metricdata.HistogramDataPoint[float64]{
+		Attributes: attributes(m),
+		StartTime:  startTime,
+		Time:       now,
+		Count:      h.GetSampleCount(),
+		Sum:        h.GetSampleSum(),
+	}
+	var cumulative uint64
+	for _, b := range h.GetBucket() {
+		// The +Inf bucket is implied
+		if math.IsInf(b.GetUpperBound(), 1) {
+			continue
+		}
+		dp.Bounds = append(dp.Bounds, b.GetUpperBound())
+		dp.BucketCounts = append(dp.BucketCounts, b.GetCumulativeCount()-cumulative)
+		cumulative = b.GetCumulativeCount()
+	}
+	dp.BucketCounts = append(dp.BucketCounts, h.GetSampleCount()-cumulative)
+	return dp
+}
+
+// Produce implements sdkmetric.Producer.
+func (p *Producer) Produce(ctx context.Context) ([]metricdata.ScopeMetrics, error) {
+	mfs, err := p.gatherer.Gather()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var metrics []metricdata.Metrics
+	for _, mf := range mfs {
+		switch mf.GetType() {
+		case dto.MetricType_GAUGE, dto.MetricType_UNTYPED:
+			gauge := metricdata.Gauge[float64]{}
+			for _, m := range mf.GetMetric() {
+				value := m.GetGauge().GetValue()
+				if mf.GetType() == dto.MetricType_UNTYPED {
+					value = m.GetUntyped().GetValue()
+				}
+				gauge.DataPoints = append(gauge.DataPoints, metricdata.DataPoint[float64]{
+					Attributes: attributes(m),
+					Time:       now,
+					Value:      value,
+				})
+			}
+			metrics = append(metrics, metricdata.Metrics{
+				Name:        mf.GetName(),
+				Description: mf.GetHelp(),
+				Data:        gauge,
+			})
+		case dto.MetricType_COUNTER:
+			sum := metricdata.Sum[float64]{
+				Temporality: metricdata.CumulativeTemporality,
+				IsMonotonic: true,
+			}
+			for _, m := range mf.GetMetric() {
+				sum.DataPoints = append(sum.DataPoints, metricdata.DataPoint[float64]{
+					Attributes: attributes(m),
+					StartTime:  p.startTime,
+					Time:       now,
+					Value:      m.GetCounter().GetValue(),
+				})
+			}
+			metrics = append(metrics, metricdata.Metrics{
+				Name:        mf.GetName(),
+				Description: mf.GetHelp(),
+				Data:        sum,
+			})
+		case dto.MetricType_SUMMARY:
+			summary := metricdata.Summary{}
+			for _, m := range mf.GetMetric() {
+				s := m.GetSummary()
+				dp := metricdata.SummaryDataPoint{
+					Attributes: attributes(m),
+					StartTime:  p.startTime,
+					Time:       now,
+					Count:      s.GetSampleCount(),
+					Sum:        s.GetSampleSum(),
+				}
+				for _, q := range s.GetQuantile() {
+					dp.QuantileValues = append(dp.QuantileValues, metricdata.QuantileValue{
+						Quantile: q.GetQuantile(),
+						Value:    q.GetValue(),
+					})
+				}
+				summary.DataPoints = append(summary.DataPoints, dp)
+			}
+			metrics = append(metrics, metricdata.Metrics{
+				Name:        mf.GetName(),
+				Description: mf.GetHelp(),
+				Data:        summary,
+			})
+		case dto.MetricType_HISTOGRAM:
+			histogram := metricdata.Histogram[float64]{
+				Temporality: metricdata.CumulativeTemporality,
+			}
+			for _, m := range mf.GetMetric() {
+				histogram.DataPoints = append(histogram.DataPoints, histogramDataPoint(m, p.startTime, now))
+			}
+			metrics = append(metrics, metricdata.Metrics{
+				Name:        mf.GetName(),
+				Description: mf.GetHelp(),
+				Data:        histogram,
+			})
+		default:
+			slog.Warn("Skipping metric of unsupported type", "name", mf.GetName(), "type", mf.GetType())
+		}
+	}
+
+	return []metricdata.ScopeMetrics{{
+		Scope:   instrumentation.Scope{Name: scopeName},
+		Metrics: metrics,
+	}}, nil
+}
+
+// NewResource returns a resource describing the cable modem, which was probed at target. cm
+// may be nil when the cable modem could not be identified yet, in which case only the target
+// is set.
+func NewResource(target string, cm *hub6.CableModem) (*resource.Resource, error) {
+	attrs := []attribute.KeyValue{
+		attribute.String("service.name", "virginmedia_hub6_exporter"),
+		attribute.String("hub6.target", target),
+	}
+	if cm != nil {
+		attrs = append(attrs,
+			attribute.String("hub6.mac_address", cm.MacAddress),
+			attribute.String("hub6.serial_number", cm.SerialNumber),
+			attribute.String("hub6.docsis_version", cm.DocsisVersion),
+		)
+	}
+	return resource.Merge(resource.Default(), resource.NewSchemaless(attrs...))
+}
+
+// NewExporter creates an OTLP metric exporter using protocol, which must be either "grpc" or
+// "http". If endpoint is empty, the standard OTEL_EXPORTER_OTLP_* environment variables are
+// used.
+func NewExporter(ctx context.Context, protocol, endpoint string, insecure bool) (sdkmetric.Exporter, error) {
+	switch protocol {
+	case "grpc":
+		var opts []otlpmetricgrpc.Option
+		if endpoint != "" {
+			opts = append(opts, otlpmetricgrpc.WithEndpoint(endpoint))
+		}
+		if insecure {
+			opts = append(opts, otlpmetricgrpc.WithInsecure())
+		}
+		return otlpmetricgrpc.New(ctx, opts...)
+	case "http":
+		var opts []otlpmetrichttp.Option
+		if endpoint != "" {
+			opts = append(opts, otlpmetrichttp.WithEndpoint(endpoint))
+		}
+		if insecure {
+			opts = append(opts, otlpmetrichttp.WithInsecure())
+		}
+		return otlpmetrichttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unsupported OTLP protocol %#v: must be grpc or http", protocol)
+	}
+}
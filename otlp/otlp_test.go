@@ -0,0 +1,138 @@
+package otlp
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	hub6 "github.com/fornellas/virginmedia_hub6_exporter/hub6"
+)
+
+func TestProducerProduce(t *testing.T) {
+	registry := prometheus.NewRegistry()
+
+	gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "test_gauge", Help: "A gauge"}, []string{"channel_id"})
+	gauge.WithLabelValues("1").Set(3.5)
+	counter := prometheus.NewCounter(prometheus.CounterOpts{Name: "test_total", Help: "A counter"})
+	counter.Add(42)
+	summary := prometheus.NewSummary(prometheus.SummaryOpts{
+		Name: "test_summary", Help: "A summary", Objectives: map[float64]float64{0.5: 0.05},
+	})
+	summary.Observe(1)
+	summary.Observe(3)
+	histogram := prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name: "test_histogram", Help: "A histogram", Buckets: []float64{1, 5},
+	})
+	for _, v := range []float64{0.5, 2, 3, 10} {
+		histogram.Observe(v)
+	}
+	registry.MustRegister(gauge, counter, summary, histogram)
+
+	var producer sdkmetric.Producer = NewProducer(registry)
+	scopeMetrics, err := producer.Produce(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scopeMetrics) != 1 || scopeMetrics[0].Scope.Name != scopeName {
+		t.Fatalf("unexpected scope metrics: %+v", scopeMetrics)
+	}
+	metrics := map[string]metricdata.Metrics{}
+	for _, m := range scopeMetrics[0].Metrics {
+		metrics[m.Name] = m
+	}
+
+	g, ok := metrics["test_gauge"].Data.(metricdata.Gauge[float64])
+	if !ok || len(g.DataPoints) != 1 {
+		t.Fatalf("unexpected gauge: %+v", metrics["test_gauge"])
+	}
+	if metrics["test_gauge"].Description != "A gauge" {
+		t.Errorf("unexpected description: %s", metrics["test_gauge"].Description)
+	}
+	if g.DataPoints[0].Value != 3.5 {
+		t.Errorf("gauge: got %v, expected 3.5", g.DataPoints[0].Value)
+	}
+	if value, ok := g.DataPoints[0].Attributes.Value("channel_id"); !ok || value != attribute.StringValue("1") {
+		t.Errorf("gauge: unexpected attributes %v", g.DataPoints[0].Attributes.ToSlice())
+	}
+
+	sum, ok := metrics["test_total"].Data.(metricdata.Sum[float64])
+	if !ok || len(sum.DataPoints) != 1 {
+		t.Fatalf("unexpected counter: %+v", metrics["test_total"])
+	}
+	if !sum.IsMonotonic || sum.Temporality != metricdata.CumulativeTemporality || sum.DataPoints[0].Value != 42 {
+		t.Errorf("unexpected counter: %+v", sum)
+	}
+
+	s, ok := metrics["test_summary"].Data.(metricdata.Summary)
+	if !ok || len(s.DataPoints) != 1 {
+		t.Fatalf("unexpected summary: %+v", metrics["test_summary"])
+	}
+	if s.DataPoints[0].Count != 2 || s.DataPoints[0].Sum != 4 || len(s.DataPoints[0].QuantileValues) != 1 ||
+		s.DataPoints[0].QuantileValues[0].Quantile != 0.5 {
+		t.Errorf("unexpected summary: %+v", s.DataPoints[0])
+	}
+
+	h, ok := metrics["test_histogram"].Data.(metricdata.Histogram[float64])
+	if !ok || len(h.DataPoints) != 1 {
+		t.Fatalf("unexpected histogram: %+v", metrics["test_histogram"])
+	}
+	dp := h.DataPoints[0]
+	if dp.Count != 4 || dp.Sum != 15.5 {
+		t.Errorf("histogram: got count %d sum %v, expected 4 15.5", dp.Count, dp.Sum)
+	}
+	if !reflect.DeepEqual(dp.Bounds, []float64{1, 5}) {
+		t.Errorf("histogram: got bounds %v", dp.Bounds)
+	}
+	// Per bucket counts, with the implied +Inf bucket
+	if !reflect.DeepEqual(dp.BucketCounts, []uint64{1, 2, 1}) {
+		t.Errorf("histogram: got bucket counts %v", dp.BucketCounts)
+	}
+}
+
+func TestNewResource(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		cm       *hub6.CableModem
+		expected map[attribute.Key]string
+	}{
+		{
+			name: "unknown cable modem",
+			expected: map[attribute.Key]string{
+				"service.name": "virginmedia_hub6_exporter",
+				"hub6.target":  "192.168.0.1",
+			},
+		},
+		{
+			name: "cable modem",
+			cm:   &hub6.CableModem{MacAddress: "8C:9A:8F:57:77:30", SerialNumber: "YBES51534445", DocsisVersion: "3.1"},
+			expected: map[attribute.Key]string{
+				"service.name":        "virginmedia_hub6_exporter",
+				"hub6.target":         "192.168.0.1",
+				"hub6.mac_address":    "8C:9A:8F:57:77:30",
+				"hub6.serial_number":  "YBES51534445",
+				"hub6.docsis_version": "3.1",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := NewResource("192.168.0.1", tc.cm)
+			if err != nil {
+				t.Fatal(err)
+			}
+			set := res.Set()
+			for key, expected := range tc.expected {
+				if value, ok := set.Value(key); !ok || value.AsString() != expected {
+					t.Errorf("%s: got %v, expected %s", key, value.Emit(), expected)
+				}
+			}
+			if _, ok := set.Value("hub6.mac_address"); ok != (tc.cm != nil) {
+				t.Errorf("unexpected hub6.mac_address presence: %v", ok)
+			}
+		})
+	}
+}
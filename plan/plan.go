@@ -0,0 +1,54 @@
+package plan
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// Plan is the speed tier paid for. Zero rates are not checked.
+type Plan struct {
+	// Downstream rate (bps)
+	DownstreamBps uint64 `json:"downstreamBps"`
+	// Upstream rate (bps)
+	UpstreamBps uint64 `json:"upstreamBps"`
+}
+
+// Rate returns the rate of direction (downstream or upstream), or zero if not declared.
+func (p Plan) Rate(direction string) uint64 {
+	switch direction {
+	case "downstream":
+		return p.DownstreamBps
+	case "upstream":
+		return p.UpstreamBps
+	default:
+		return 0
+	}
+}
+
+// Plans holds the Plan of each target. The "" key applies to targets without a Plan of their
+// own.
+type Plans map[string]Plan
+
+// Lookup returns the Plan for target, and whether there's one.
+func (p Plans) Lookup(target string) (Plan, bool) {
+	if plan, ok := p[target]; ok {
+		return plan, true
+	}
+	plan, ok := p[""]
+	return plan, ok
+}
+
+// LoadPlans loads Plans from a JSON file, eg:
+//
+//	{"192.168.100.1": {"downstreamBps": 500000000, "upstreamBps": 50000000}}
+func LoadPlans(path string) (Plans, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var p Plans
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
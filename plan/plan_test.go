@@ -0,0 +1,80 @@
+package plan
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadPlans(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plans.json")
+	if err := os.WriteFile(path, []byte(`{
+		"192.168.100.1": {"downstreamBps": 500000000, "upstreamBps": 50000000},
+		"": {"downstreamBps": 1000000000}
+	}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	plans, err := LoadPlans(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Plans{
+		"192.168.100.1": {DownstreamBps: 500000000, UpstreamBps: 50000000},
+		"":              {DownstreamBps: 1000000000},
+	}
+	if !reflect.DeepEqual(plans, expected) {
+		t.Errorf("got %v, expected %v", plans, expected)
+	}
+}
+
+func TestLoadPlansErrors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := LoadPlans(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("missing file: expected error")
+	}
+
+	path := filepath.Join(dir, "invalid.json")
+	if err := os.WriteFile(path, []byte(`{"192.168.100.1": {"downstreamBps": "fast"}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadPlans(path); err == nil {
+		t.Error("invalid file: expected error")
+	}
+}
+
+func TestPlansLookup(t *testing.T) {
+	target := Plan{DownstreamBps: 500000000, UpstreamBps: 50000000}
+	fallback := Plan{DownstreamBps: 1000000000}
+	for _, tc := range []struct {
+		name     string
+		plans    Plans
+		target   string
+		expected Plan
+		ok       bool
+	}{
+		{"own plan", Plans{"192.168.100.1": target, "": fallback}, "192.168.100.1", target, true},
+		{"fallback", Plans{"192.168.100.1": target, "": fallback}, "192.168.0.1", fallback, true},
+		{"no plan", Plans{"192.168.100.1": target}, "192.168.0.1", Plan{}, false},
+		{"no plans", nil, "192.168.0.1", Plan{}, false},
+	} {
+		plan, ok := tc.plans.Lookup(tc.target)
+		if plan != tc.expected || ok != tc.ok {
+			t.Errorf("%s: got %+v %v, expected %+v %v", tc.name, plan, ok, tc.expected, tc.ok)
+		}
+	}
+}
+
+func TestPlanRate(t *testing.T) {
+	p := Plan{DownstreamBps: 500000000, UpstreamBps: 50000000}
+	for direction, expected := range map[string]uint64{
+		"downstream": 500000000,
+		"upstream":   50000000,
+		"sideways":   0,
+	} {
+		if rate := p.Rate(direction); rate != expected {
+			t.Errorf("%s: got %d, expected %d", direction, rate, expected)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package remotewrite
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// https://prometheus.io/docs/specs/prw/remote_write_spec/
+const (
+	contentType = "application/x-protobuf"
+	version     = "0.1.0"
+)
+
+type label struct {
+	name  string
+	value string
+}
+
+type sample struct {
+	value     float64
+	timestamp int64
+}
+
+type timeSeries struct {
+	labels []label
+	sample sample
+}
+
+// Client sends metrics to a Prometheus remote_write endpoint.
+type Client struct {
+	url      string
+	client   *http.Client
+	labels   map[string]string
+	username string
+	password string
+}
+
+// NewClient creates a new Client that sends metrics to url. timeout is applied to each HTTP
+// request.
+func NewClient(url string, timeout time.Duration) *Client {
+	return &Client{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+		labels: map[string]string{},
+	}
+}
+
+// Label adds a label with the given name and value to all sent time series.
+func (c *Client) Label(name, value string) *Client {
+	c.labels[name] = value
+	return c
+}
+
+// BasicAuth configures HTTP basic authentication for all requests.
+func (c *Client) BasicAuth(username, password string) *Client {
+	c.username = username
+	c.password = password
+	return c
+}
+
+// Write gathers all metrics from g and sends them to the remote_write endpoint as a single
+// snappy compressed protobuf WriteRequest.
+func (c *Client) Write(ctx context.Context, g prometheus.Gatherer) error {
+	mfs, err := g.Gather()
+	if err != nil {
+		return err
+	}
+
+	timestamp := time.Now().UnixMilli()
+	var series []timeSeries
+	for _, mf := range mfs {
+		series = append(series, c.toTimeSeries(mf, timestamp)...)
+	}
+
+	body := snappy.Encode(nil, encodeWriteRequest(series))
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("X-Prometheus-Remote-Write-Version", version)
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("unexpected status %d from %s: %s", resp.StatusCode, c.url, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+func (c *Client) newTimeSeries(name string, m *dto.Metric, extra []label, value float64, timestamp int64) timeSeries {
+	labels := []label{{name: "__name__", value: name}}
+	for n, v := range c.labels {
+		labels = append(labels, label{name: n, value: v})
+	}
+	for _, lp := range m.GetLabel() {
+		labels = append(labels, label{name: lp.GetName(), value: lp.GetValue()})
+	}
+	labels = append(labels, extra...)
+	sort.Slice(labels, func(i, j int) bool { return labels[i].name < labels[j].name })
+
+	if m.TimestampMs != nil {
+		timestamp = m.GetTimestampMs()
+	}
+
+	return timeSeries{
+		labels: labels,
+		sample: sample{value: value, timestamp: timestamp},
+	}
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func (c *Client) toTimeSeries(mf *dto.MetricFamily, timestamp int64) []timeSeries {
+	name := mf.GetName()
+	var series []timeSeries
+	for _, m := range mf.GetMetric() {
+		switch mf.GetType() {
+		case dto.MetricType_GAUGE:
+			series = append(series, c.newTimeSeries(name, m, nil, m.GetGauge().GetValue(), timestamp))
+		case dto.MetricType_COUNTER:
+			series = append(series, c.newTimeSeries(name, m, nil, m.GetCounter().GetValue(), timestamp))
+		case dto.MetricType_UNTYPED:
+			series = append(series, c.newTimeSeries(name, m, nil, m.GetUntyped().GetValue(), timestamp))
+		case dto.MetricType_SUMMARY:
+			s := m.GetSummary()
+			for _, q := range s.GetQuantile() {
+				series = append(series, c.newTimeSeries(
+					name, m, []label{{name: "quantile", value: formatFloat(q.GetQuantile())}}, q.GetValue(), timestamp,
+				))
+			}
+			series = append(series, c.newTimeSeries(name+"_sum", m, nil, s.GetSampleSum(), timestamp))
+			series = append(series, c.newTimeSeries(name+"_count", m, nil, float64(s.GetSampleCount()), timestamp))
+		case dto.MetricType_HISTOGRAM:
+			h := m.GetHistogram()
+			for _, b := range h.GetBucket() {
+				series = append(series, c.newTimeSeries(
+					name+"_bucket", m, []label{{name: "le", value: formatFloat(b.GetUpperBound())}}, float64(b.GetCumulativeCount()), timestamp,
+				))
+			}
+			series = append(series, c.newTimeSeries(
+				name+"_bucket", m, []label{{name: "le", value: "+Inf"}}, float64(h.GetSampleCount()), timestamp,
+			))
+			series = append(series, c.newTimeSeries(name+"_sum", m, nil, h.GetSampleSum(), timestamp))
+			series = append(series, c.newTimeSeries(name+"_count", m, nil, float64(h.GetSampleCount()), timestamp))
+		}
+	}
+	return series
+}
+
+// encodeWriteRequest encodes series as a prometheus.WriteRequest protobuf message:
+//
+//	message WriteRequest { repeated TimeSeries timeseries = 1; }
+//	message TimeSeries { repeated Label labels = 1; repeated Sample samples = 2; }
+//	message Label { string name = 1; string value = 2; }
+//	message Sample { double value = 1; int64 timestamp = 2; }
+func encodeWriteRequest(series []timeSeries) []byte {
+	var b []byte
+	for _, ts := range series {
+		var tsb []byte
+		for _, l := range ts.labels {
+			var lb []byte
+			lb = protowire.AppendTag(lb, 1, protowire.BytesType)
+			lb = protowire.AppendString(lb, l.name)
+			lb = protowire.AppendTag(lb, 2, protowire.BytesType)
+			lb = protowire.AppendString(lb, l.value)
+			tsb = protowire.AppendTag(tsb, 1, protowire.BytesType)
+			tsb = protowire.AppendBytes(tsb, lb)
+		}
+		var sb []byte
+		sb = protowire.AppendTag(sb, 1, protowire.Fixed64Type)
+		sb = protowire.AppendFixed64(sb, math.Float64bits(ts.sample.value))
+		sb = protowire.AppendTag(sb, 2, protowire.VarintType)
+		sb = protowire.AppendVarint(sb, uint64(ts.sample.timestamp))
+		tsb = protowire.AppendTag(tsb, 2, protowire.BytesType)
+		tsb = protowire.AppendBytes(tsb, sb)
+
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendBytes(b, tsb)
+	}
+	return b
+}
@@ -0,0 +1,192 @@
+package remotewrite
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// decodeFields decodes the protobuf message b into its fields, by number.
+func decodeFields(t *testing.T, b []byte) map[protowire.Number][]any {
+	t.Helper()
+	fields := map[protowire.Number][]any{}
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			t.Fatalf("invalid tag: %v", protowire.ParseError(n))
+		}
+		b = b[n:]
+		var value any
+		switch typ {
+		case protowire.BytesType:
+			var v []byte
+			v, n = protowire.ConsumeBytes(b)
+			value = v
+		case protowire.Fixed64Type:
+			var v uint64
+			v, n = protowire.ConsumeFixed64(b)
+			value = math.Float64frombits(v)
+		case protowire.VarintType:
+			var v uint64
+			v, n = protowire.ConsumeVarint(b)
+			value = int64(v)
+		default:
+			t.Fatalf("unexpected wire type %d", typ)
+		}
+		if n < 0 {
+			t.Fatalf("invalid field %d: %v", num, protowire.ParseError(n))
+		}
+		b = b[n:]
+		fields[num] = append(fields[num], value)
+	}
+	return fields
+}
+
+type decodedSeries struct {
+	labels    map[string]string
+	value     float64
+	timestamp int64
+}
+
+// decodeWriteRequest decodes a WriteRequest, keyed by the __name__ label of each series.
+func decodeWriteRequest(t *testing.T, b []byte) map[string][]decodedSeries {
+	t.Helper()
+	series := map[string][]decodedSeries{}
+	for _, tsb := range decodeFields(t, b)[1] {
+		ts := decodeFields(t, tsb.([]byte))
+		s := decodedSeries{labels: map[string]string{}}
+		for _, lb := range ts[1] {
+			l := decodeFields(t, lb.([]byte))
+			s.labels[string(l[1][0].([]byte))] = string(l[2][0].([]byte))
+		}
+		if len(ts[2]) != 1 {
+			t.Fatalf("expected 1 sample, got %d", len(ts[2]))
+		}
+		sample := decodeFields(t, ts[2][0].([]byte))
+		s.value = sample[1][0].(float64)
+		s.timestamp = sample[2][0].(int64)
+		name := s.labels["__name__"]
+		delete(s.labels, "__name__")
+		series[name] = append(series[name], s)
+	}
+	return series
+}
+
+func TestClientWrite(t *testing.T) {
+	var (
+		header http.Header
+		body   []byte
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		compressed, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("failed to read body: %v", err)
+		}
+		if body, err = snappy.Decode(nil, compressed); err != nil {
+			t.Errorf("failed to decompress body: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	registry := prometheus.NewRegistry()
+	gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "test_gauge", Help: "Test gauge"}, []string{"channel"})
+	gauge.WithLabelValues("1").Set(1.5)
+	gauge.WithLabelValues("2").Set(-3)
+	counter := prometheus.NewCounter(prometheus.CounterOpts{Name: "test_total", Help: "Test counter"})
+	counter.Add(42)
+	histogram := prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name: "test_seconds", Help: "Test histogram", Buckets: []float64{1, 10},
+	})
+	histogram.Observe(0.5)
+	histogram.Observe(5)
+	registry.MustRegister(gauge, counter, histogram)
+
+	before := time.Now().UnixMilli()
+	err := NewClient(server.URL, time.Second).
+		Label("target", "192.168.0.1").
+		BasicAuth("user", "pass").
+		Write(context.Background(), registry)
+	if err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	after := time.Now().UnixMilli()
+
+	for name, value := range map[string]string{
+		"Content-Encoding":                  "snappy",
+		"Content-Type":                      "application/x-protobuf",
+		"X-Prometheus-Remote-Write-Version": "0.1.0",
+	} {
+		if got := header.Get(name); got != value {
+			t.Errorf("header %s: got %q, expected %q", name, got, value)
+		}
+	}
+	req := &http.Request{Header: header}
+	if username, password, ok := req.BasicAuth(); !ok || username != "user" || password != "pass" {
+		t.Errorf("unexpected basic auth: %q %q %v", username, password, ok)
+	}
+
+	series := decodeWriteRequest(t, body)
+	for _, ss := range series {
+		for _, s := range ss {
+			if s.timestamp < before || s.timestamp > after {
+				t.Errorf("timestamp %d outside of [%d, %d]", s.timestamp, before, after)
+			}
+		}
+	}
+	type labelsValue struct {
+		labels map[string]string
+		value  float64
+	}
+	for name, expected := range map[string][]labelsValue{
+		"test_gauge": {
+			{map[string]string{"target": "192.168.0.1", "channel": "1"}, 1.5},
+			{map[string]string{"target": "192.168.0.1", "channel": "2"}, -3},
+		},
+		"test_total": {{map[string]string{"target": "192.168.0.1"}, 42}},
+		"test_seconds_bucket": {
+			{map[string]string{"target": "192.168.0.1", "le": "1"}, 1},
+			{map[string]string{"target": "192.168.0.1", "le": "10"}, 2},
+			{map[string]string{"target": "192.168.0.1", "le": "+Inf"}, 2},
+		},
+		"test_seconds_sum":   {{map[string]string{"target": "192.168.0.1"}, 5.5}},
+		"test_seconds_count": {{map[string]string{"target": "192.168.0.1"}, 2}},
+	} {
+		var got []labelsValue
+		for _, s := range series[name] {
+			got = append(got, labelsValue{s.labels, s.value})
+		}
+		if !reflect.DeepEqual(got, expected) {
+			t.Errorf("%s: got %v, expected %v", name, got, expected)
+		}
+	}
+	if len(series) != 5 {
+		t.Errorf("expected 5 metric names, got %d", len(series))
+	}
+}
+
+func TestClientWriteError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "out of order sample", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	err := NewClient(server.URL, time.Second).Write(context.Background(), prometheus.NewRegistry())
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	expected := "unexpected status 400 from " + server.URL + ": out of order sample"
+	if err.Error() != expected {
+		t.Errorf("got %q, expected %q", err.Error(), expected)
+	}
+}
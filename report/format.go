@@ -0,0 +1,151 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+)
+
+// Formats supported by Write.
+const (
+	Markdown = "markdown"
+	HTML     = "html"
+	JSON     = "json"
+)
+
+func formatDuration(seconds float64) string {
+	return time.Duration(seconds * float64(time.Second)).Round(time.Second).String()
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04:05 UTC")
+}
+
+func formatOptional(seconds float64) string {
+	if seconds == 0 {
+		return "n/a"
+	}
+	return formatDuration(seconds)
+}
+
+func formatEnd(o Outage) string {
+	if o.Ongoing {
+		return "ongoing"
+	}
+	return formatTime(o.End)
+}
+
+func writeMarkdown(w io.Writer, reports []Report) error {
+	var b strings.Builder
+	b.WriteString("# Virgin Media Hub availability report\n")
+	for _, r := range reports {
+		fmt.Fprintf(&b, "\n## %s\n\n", r.Target)
+		fmt.Fprintf(&b, "- Period: %s to %s\n", formatTime(r.Start), formatTime(r.End))
+		fmt.Fprintf(&b, "- Observed: %s\n", formatDuration(r.ObservedSeconds))
+		fmt.Fprintf(&b, "- Availability: %.3f%%\n", r.AvailabilityPercent)
+		fmt.Fprintf(&b, "- Downtime: %s in %d outages\n", formatDuration(r.DowntimeSeconds), len(r.Outages))
+		fmt.Fprintf(&b, "- Reboots: %d\n", len(r.Reboots))
+		fmt.Fprintf(&b, "- MTBF: %s\n", formatOptional(r.MtbfSeconds))
+		fmt.Fprintf(&b, "- MTTR: %s\n", formatOptional(r.MttrSeconds))
+
+		b.WriteString("\n### Monthly uptime\n\n")
+		b.WriteString("| Month | Observed | Downtime | Uptime |\n")
+		b.WriteString("|---|---|---|---|\n")
+		for _, m := range r.Months {
+			fmt.Fprintf(&b, "| %s | %s | %s | %.3f%% |\n",
+				m.Month, formatDuration(m.ObservedSeconds), formatDuration(m.DowntimeSeconds), m.UptimePercent)
+		}
+
+		b.WriteString("\n### Outages\n\n")
+		if len(r.Outages) == 0 {
+			b.WriteString("None.\n")
+		} else {
+			b.WriteString("| Start | End | Duration | Causes |\n")
+			b.WriteString("|---|---|---|---|\n")
+			for _, o := range r.Outages {
+				fmt.Fprintf(&b, "| %s | %s | %s | %s |\n",
+					formatTime(o.Start), formatEnd(o), formatDuration(o.DurationSeconds), strings.Join(o.Causes, ", "))
+			}
+		}
+
+		if len(r.Reboots) > 0 {
+			b.WriteString("\n### Reboots\n\n")
+			for _, t := range r.Reboots {
+				fmt.Fprintf(&b, "- %s\n", formatTime(t))
+			}
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"duration":  formatDuration,
+	"optional":  formatOptional,
+	"time":      formatTime,
+	"outageEnd": formatEnd,
+	"join":      strings.Join,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Virgin Media Hub availability report</title>
+<style>
+  body { font-family: sans-serif; margin: 1em 2em; color: #222; }
+  table { border-collapse: collapse; margin-bottom: 1em; }
+  th, td { border: 1px solid #ccc; padding: 0.2em 0.6em; text-align: left; }
+</style>
+</head>
+<body>
+<h1>Virgin Media Hub availability report</h1>
+{{range .}}
+<h2>{{.Target}}</h2>
+<ul>
+  <li>Period: {{time .Start}} to {{time .End}}</li>
+  <li>Observed: {{duration .ObservedSeconds}}</li>
+  <li>Availability: {{printf "%.3f" .AvailabilityPercent}}%</li>
+  <li>Downtime: {{duration .DowntimeSeconds}} in {{len .Outages}} outages</li>
+  <li>Reboots: {{len .Reboots}}</li>
+  <li>MTBF: {{optional .MtbfSeconds}}</li>
+  <li>MTTR: {{optional .MttrSeconds}}</li>
+</ul>
+<h3>Monthly uptime</h3>
+<table>
+  <tr><th>Month</th><th>Observed</th><th>Downtime</th><th>Uptime</th></tr>
+  {{range .Months}}<tr><td>{{.Month}}</td><td>{{duration .ObservedSeconds}}</td><td>{{duration .DowntimeSeconds}}</td><td>{{printf "%.3f" .UptimePercent}}%</td></tr>
+  {{end}}
+</table>
+<h3>Outages</h3>
+{{if .Outages}}<table>
+  <tr><th>Start</th><th>End</th><th>Duration</th><th>Causes</th></tr>
+  {{range .Outages}}<tr><td>{{time .Start}}</td><td>{{outageEnd .}}</td><td>{{duration .DurationSeconds}}</td><td>{{join .Causes ", "}}</td></tr>
+  {{end}}
+</table>{{else}}<p>None.</p>{{end}}
+{{if .Reboots}}<h3>Reboots</h3>
+<ul>
+  {{range .Reboots}}<li>{{time .}}</li>
+  {{end}}
+</ul>{{end}}
+{{end}}
+</body>
+</html>
+`))
+
+// Write writes reports to w in format: markdown, html or json.
+func Write(w io.Writer, format string, reports []Report) error {
+	switch format {
+	case Markdown:
+		return writeMarkdown(w, reports)
+	case HTML:
+		return htmlTemplate.Execute(w, reports)
+	case JSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(reports)
+	default:
+		return fmt.Errorf("unknown report format: %#v", format)
+	}
+}
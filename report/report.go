@@ -0,0 +1,200 @@
+package report
+
+import (
+	"sort"
+	"time"
+)
+
+// Sample is the state of a Hub at a point in time.
+type Sample struct {
+	Time time.Time
+	// Whether the Hub could be scraped
+	Reachable bool
+	// Empty if unknown
+	Status string
+	// Zero if unknown
+	UptimeSeconds uint64
+}
+
+// Outage causes
+const (
+	CauseUnreachable    = "unreachable"
+	CauseNotOperational = "not_operational"
+	CauseReboot         = "reboot"
+)
+
+// Outage is a period when the Hub was not operational.
+type Outage struct {
+	Start time.Time `json:"start"`
+	// Time of the first operational sample after the outage; the end of the report if ongoing
+	End     time.Time `json:"end"`
+	Ongoing bool      `json:"ongoing"`
+	// Causes seen during the outage, eg: unreachable or not_operational (status)
+	Causes          []string `json:"causes"`
+	DurationSeconds float64  `json:"durationSeconds"`
+}
+
+// Month is the availability of a calendar month (UTC).
+type Month struct {
+	// eg: 2026-01
+	Month string `json:"month"`
+	// Time covered by samples
+	ObservedSeconds float64 `json:"observedSeconds"`
+	DowntimeSeconds float64 `json:"downtimeSeconds"`
+	UptimePercent   float64 `json:"uptimePercent"`
+}
+
+// Report is the availability report of a Hub.
+type Report struct {
+	Target string    `json:"target"`
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+	// Time covered by samples; gaps longer than the maximum gap are not observed
+	ObservedSeconds     float64     `json:"observedSeconds"`
+	DowntimeSeconds     float64     `json:"downtimeSeconds"`
+	AvailabilityPercent float64     `json:"availabilityPercent"`
+	Outages             []Outage    `json:"outages"`
+	Reboots             []time.Time `json:"reboots"`
+	// Mean time between failures (outages); zero if there were no outages
+	MtbfSeconds float64 `json:"mtbfSeconds"`
+	// Mean time to repair (outage duration); zero if there were no outages
+	MttrSeconds float64 `json:"mttrSeconds"`
+	Months      []Month `json:"months"`
+}
+
+// rebootTolerance is the maximum difference between boot times (sample time minus uptime)
+// which are considered the same boot, accounting for clock and sampling jitter.
+const rebootTolerance = time.Minute
+
+func monthOf(t time.Time) string {
+	return t.UTC().Format("2006-01")
+}
+
+// addByMonth adds the duration between start and end to m, split by calendar month.
+func addByMonth(m map[string]float64, start, end time.Time) {
+	start, end = start.UTC(), end.UTC()
+	for start.Before(end) {
+		nextMonth := time.Date(start.Year(), start.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		until := end
+		if nextMonth.Before(end) {
+			until = nextMonth
+		}
+		m[monthOf(start)] += until.Sub(start).Seconds()
+		start = until
+	}
+}
+
+// Analyze builds the Report of target from samples. Each sample is taken to represent the Hub
+// until the next one, up to maxGap; longer gaps are not observed.
+func Analyze(target string, samples []Sample, maxGap time.Duration) Report {
+	samples = append([]Sample(nil), samples...)
+	sort.Slice(samples, func(i, j int) bool { return samples[i].Time.Before(samples[j].Time) })
+
+	r := Report{Target: target, Outages: []Outage{}, Reboots: []time.Time{}, Months: []Month{}}
+	if len(samples) == 0 {
+		return r
+	}
+	r.Start = samples[0].Time
+	r.End = samples[len(samples)-1].Time
+
+	observed := map[string]float64{}
+	for i, s := range samples[:len(samples)-1] {
+		until := samples[i+1].Time
+		if until.Sub(s.Time) > maxGap {
+			until = s.Time.Add(maxGap)
+		}
+		addByMonth(observed, s.Time, until)
+	}
+
+	var outage *Outage
+	addCause := func(o *Outage, cause string) {
+		for _, c := range o.Causes {
+			if c == cause {
+				return
+			}
+		}
+		o.Causes = append(o.Causes, cause)
+	}
+	var bootTime, previousTime time.Time
+	for _, s := range samples {
+		// Reboots
+		if s.UptimeSeconds > 0 {
+			sampleBootTime := s.Time.Add(-time.Duration(s.UptimeSeconds) * time.Second)
+			if !bootTime.IsZero() && sampleBootTime.Sub(bootTime) > rebootTolerance {
+				r.Reboots = append(r.Reboots, sampleBootTime)
+				switch {
+				case outage != nil:
+					addCause(outage, CauseReboot)
+				// Reboots between samples are outages up to the sample which saw them
+				case sampleBootTime.After(previousTime):
+					outage = &Outage{Start: sampleBootTime}
+					addCause(outage, CauseReboot)
+				// Otherwise, the reboot was during an earlier outage
+				case len(r.Outages) > 0 && !sampleBootTime.After(r.Outages[len(r.Outages)-1].End):
+					addCause(&r.Outages[len(r.Outages)-1], CauseReboot)
+				}
+			}
+			bootTime = sampleBootTime
+		}
+		previousTime = s.Time
+
+		// Outages
+		switch {
+		case !s.Reachable:
+			if outage == nil {
+				outage = &Outage{Start: s.Time}
+			}
+			addCause(outage, CauseUnreachable)
+		case s.Status != "operational":
+			if outage == nil {
+				outage = &Outage{Start: s.Time}
+			}
+			addCause(outage, CauseNotOperational)
+		case outage != nil:
+			outage.End = s.Time
+			r.Outages = append(r.Outages, *outage)
+			outage = nil
+		}
+	}
+	if outage != nil {
+		outage.End = r.End
+		outage.Ongoing = true
+		r.Outages = append(r.Outages, *outage)
+	}
+
+	downtime := map[string]float64{}
+	for i := range r.Outages {
+		o := &r.Outages[i]
+		o.DurationSeconds = o.End.Sub(o.Start).Seconds()
+		r.DowntimeSeconds += o.DurationSeconds
+		addByMonth(downtime, o.Start, o.End)
+	}
+
+	months := make([]string, 0, len(observed))
+	for month := range observed {
+		months = append(months, month)
+	}
+	sort.Strings(months)
+	var observedDowntime float64
+	for _, month := range months {
+		m := Month{Month: month, ObservedSeconds: observed[month], DowntimeSeconds: downtime[month]}
+		// Outages may span unobserved gaps
+		m.DowntimeSeconds = min(m.DowntimeSeconds, m.ObservedSeconds)
+		if m.ObservedSeconds > 0 {
+			m.UptimePercent = 100 * (m.ObservedSeconds - m.DowntimeSeconds) / m.ObservedSeconds
+		}
+		r.ObservedSeconds += m.ObservedSeconds
+		observedDowntime += m.DowntimeSeconds
+		r.Months = append(r.Months, m)
+	}
+
+	if r.ObservedSeconds > 0 {
+		r.AvailabilityPercent = 100 * (r.ObservedSeconds - observedDowntime) / r.ObservedSeconds
+	}
+	if n := len(r.Outages); n > 0 {
+		r.MtbfSeconds = (r.ObservedSeconds - observedDowntime) / float64(n)
+		r.MttrSeconds = r.DowntimeSeconds / float64(n)
+	}
+
+	return r
+}
@@ -0,0 +1,163 @@
+package report
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAnalyze(t *testing.T) {
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return start.Add(time.Duration(minutes) * time.Minute) }
+	// Samples every minute from 0 to 10, operational and without reboots unless overridden
+	samples := func(overrides map[int]Sample) []Sample {
+		var samples []Sample
+		for i := 0; i <= 10; i++ {
+			s, ok := overrides[i]
+			if !ok {
+				s = Sample{Reachable: true, Status: "operational", UptimeSeconds: 100000 + uint64(i)*60}
+			}
+			s.Time = at(i)
+			samples = append(samples, s)
+		}
+		return samples
+	}
+	unreachable := Sample{}
+
+	for _, tc := range []struct {
+		name     string
+		samples  []Sample
+		expected Report
+	}{
+		{
+			name:    "no samples",
+			samples: nil,
+			expected: Report{
+				Target: "hub", Outages: []Outage{}, Reboots: []time.Time{}, Months: []Month{},
+			},
+		},
+		{
+			name:    "operational",
+			samples: samples(nil),
+			expected: Report{
+				Target: "hub", Start: at(0), End: at(10),
+				ObservedSeconds: 600, AvailabilityPercent: 100,
+				Outages: []Outage{}, Reboots: []time.Time{},
+				Months: []Month{{Month: "2026-03", ObservedSeconds: 600, UptimePercent: 100}},
+			},
+		},
+		{
+			name:    "unreachable",
+			samples: samples(map[int]Sample{3: unreachable, 4: unreachable}),
+			expected: Report{
+				Target: "hub", Start: at(0), End: at(10),
+				ObservedSeconds: 600, DowntimeSeconds: 120, AvailabilityPercent: 80,
+				Outages: []Outage{
+					{Start: at(3), End: at(5), Causes: []string{CauseUnreachable}, DurationSeconds: 120},
+				},
+				Reboots:     []time.Time{},
+				MtbfSeconds: 480, MttrSeconds: 120,
+				Months: []Month{{Month: "2026-03", ObservedSeconds: 600, DowntimeSeconds: 120, UptimePercent: 80}},
+			},
+		},
+		{
+			name: "not operational until the end",
+			samples: samples(map[int]Sample{
+				7:  {Reachable: true, Status: "ranging", UptimeSeconds: 100000 + 7*60},
+				8:  unreachable,
+				9:  unreachable,
+				10: {Reachable: true, Status: "ranging", UptimeSeconds: 100000 + 10*60},
+			}),
+			expected: Report{
+				Target: "hub", Start: at(0), End: at(10),
+				ObservedSeconds: 600, DowntimeSeconds: 180, AvailabilityPercent: 70,
+				Outages: []Outage{{
+					Start: at(7), End: at(10), Ongoing: true,
+					Causes: []string{CauseNotOperational, CauseUnreachable}, DurationSeconds: 180,
+				}},
+				Reboots:     []time.Time{},
+				MtbfSeconds: 420, MttrSeconds: 180,
+				Months: []Month{{Month: "2026-03", ObservedSeconds: 600, DowntimeSeconds: 180, UptimePercent: 70}},
+			},
+		},
+		{
+			name: "reboot between samples",
+			samples: samples(map[int]Sample{
+				5: {Reachable: true, Status: "operational", UptimeSeconds: 30},
+				6: {Reachable: true, Status: "operational", UptimeSeconds: 90},
+			}),
+			expected: Report{
+				Target: "hub", Start: at(0), End: at(10),
+				ObservedSeconds: 600, DowntimeSeconds: 30, AvailabilityPercent: 95,
+				Outages: []Outage{
+					{Start: at(5).Add(-30 * time.Second), End: at(5), Causes: []string{CauseReboot}, DurationSeconds: 30},
+				},
+				Reboots:     []time.Time{at(5).Add(-30 * time.Second)},
+				MtbfSeconds: 570, MttrSeconds: 30,
+				Months: []Month{{Month: "2026-03", ObservedSeconds: 600, DowntimeSeconds: 30, UptimePercent: 95}},
+			},
+		},
+		{
+			name: "reboot during an ongoing outage",
+			samples: samples(map[int]Sample{
+				3: unreachable,
+				4: unreachable,
+				5: {Reachable: true, Status: "operational", UptimeSeconds: 90},
+			}),
+			expected: Report{
+				Target: "hub", Start: at(0), End: at(10),
+				ObservedSeconds: 600, DowntimeSeconds: 120, AvailabilityPercent: 80,
+				Outages: []Outage{
+					{Start: at(3), End: at(5), Causes: []string{CauseUnreachable, CauseReboot}, DurationSeconds: 120},
+				},
+				Reboots:     []time.Time{at(5).Add(-90 * time.Second)},
+				MtbfSeconds: 480, MttrSeconds: 120,
+				Months: []Month{{Month: "2026-03", ObservedSeconds: 600, DowntimeSeconds: 120, UptimePercent: 80}},
+			},
+		},
+		{
+			name: "reboot during an earlier outage",
+			samples: samples(map[int]Sample{
+				3: unreachable,
+				// Uptime unknown
+				4: {Reachable: true, Status: "operational"},
+				5: {Reachable: true, Status: "operational", UptimeSeconds: 150},
+			}),
+			expected: Report{
+				Target: "hub", Start: at(0), End: at(10),
+				ObservedSeconds: 600, DowntimeSeconds: 60, AvailabilityPercent: 90,
+				Outages: []Outage{
+					{Start: at(3), End: at(4), Causes: []string{CauseUnreachable, CauseReboot}, DurationSeconds: 60},
+				},
+				Reboots:     []time.Time{at(5).Add(-150 * time.Second)},
+				MtbfSeconds: 540, MttrSeconds: 60,
+				Months: []Month{{Month: "2026-03", ObservedSeconds: 600, DowntimeSeconds: 60, UptimePercent: 90}},
+			},
+		},
+		{
+			name: "gap and months",
+			samples: []Sample{
+				{Time: at(-2), Reachable: true, Status: "operational", UptimeSeconds: 100000},
+				{Time: at(-1), Reachable: true, Status: "operational", UptimeSeconds: 100060},
+				// Unobserved after 5 minutes
+				{Time: at(20), Reachable: true, Status: "operational", UptimeSeconds: 101260},
+				{Time: at(21), Reachable: true, Status: "operational", UptimeSeconds: 101320},
+			},
+			expected: Report{
+				Target: "hub", Start: at(-2), End: at(21),
+				ObservedSeconds: 420, AvailabilityPercent: 100,
+				Outages: []Outage{}, Reboots: []time.Time{},
+				Months: []Month{
+					{Month: "2026-02", ObservedSeconds: 120, UptimePercent: 100},
+					{Month: "2026-03", ObservedSeconds: 300, UptimePercent: 100},
+				},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Analyze("hub", tc.samples, 5*time.Minute); !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("got:\n%+v\nexpected:\n%+v", got, tc.expected)
+			}
+		})
+	}
+}
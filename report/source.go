@@ -0,0 +1,284 @@
+package report
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fornellas/virginmedia_hub6_exporter/history"
+)
+
+func getJSON(ctx context.Context, client *http.Client, u string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, u)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode JSON from %s: %w", u, err)
+	}
+	return nil
+}
+
+// FromHistory fetches samples of all targets from the history served by the server command at
+// historyURL, eg: http://localhost:9188/history/.
+func FromHistory(ctx context.Context, client *http.Client, historyURL string) (map[string][]Sample, error) {
+	dataURL, err := url.JoinPath(historyURL, "data")
+	if err != nil {
+		return nil, err
+	}
+
+	var targets struct {
+		Targets []string `json:"targets"`
+	}
+	if err := getJSON(ctx, client, dataURL, &targets); err != nil {
+		return nil, err
+	}
+
+	samplesByTarget := map[string][]Sample{}
+	for _, target := range targets.Targets {
+		var data struct {
+			Samples []history.Sample `json:"samples"`
+		}
+		if err := getJSON(ctx, client, dataURL+"?target="+url.QueryEscape(target), &data); err != nil {
+			return nil, err
+		}
+		samples := make([]Sample, len(data.Samples))
+		for i, s := range data.Samples {
+			samples[i] = Sample{
+				Time: s.Time,
+				// Unreachable Hubs have no state
+				Reachable:     s.Status != "",
+				Status:        s.Status,
+				UptimeSeconds: s.UptimeSeconds,
+			}
+		}
+		samplesByTarget[target] = samples
+	}
+	return samplesByTarget, nil
+}
+
+// maxPoints is the maximum number of points per series Prometheus returns from a range query.
+const maxPoints = 11000
+
+// seriesKey returns a key identifying the series with labels.
+func seriesKey(labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&b, "%s=%q,", name, labels[name])
+	}
+	return b.String()
+}
+
+// queryRangeChunk runs a single Prometheus range query, and returns the labels of each series,
+// along with its values by Unix timestamp.
+func queryRangeChunk(
+	ctx context.Context, client *http.Client, prometheusURL, query string, start, end time.Time, step time.Duration,
+) ([]map[string]string, []map[int64]float64, error) {
+	apiURL, err := url.JoinPath(prometheusURL, "api/v1/query_range")
+	if err != nil {
+		return nil, nil, err
+	}
+	params := url.Values{}
+	params.Set("query", query)
+	params.Set("start", strconv.FormatInt(start.Unix(), 10))
+	params.Set("end", strconv.FormatInt(end.Unix(), 10))
+	params.Set("step", strconv.FormatFloat(step.Seconds(), 'f', -1, 64))
+
+	var response struct {
+		Status string `json:"status"`
+		Error  string `json:"error"`
+		Data   struct {
+			Result []struct {
+				Metric map[string]string `json:"metric"`
+				Values [][2]any          `json:"values"`
+			} `json:"result"`
+		} `json:"data"`
+	}
+	if err := getJSON(ctx, client, apiURL+"?"+params.Encode(), &response); err != nil {
+		return nil, nil, err
+	}
+	if response.Status != "success" {
+		return nil, nil, fmt.Errorf("query %#v failed: %s", query, response.Error)
+	}
+
+	var metrics []map[string]string
+	var values []map[int64]float64
+	for _, series := range response.Data.Result {
+		seriesValues := map[int64]float64{}
+		for _, v := range series.Values {
+			timestamp, ok := v[0].(float64)
+			if !ok {
+				return nil, nil, fmt.Errorf("query %#v: invalid timestamp %#v", query, v[0])
+			}
+			valueStr, ok := v[1].(string)
+			if !ok {
+				return nil, nil, fmt.Errorf("query %#v: invalid value %#v", query, v[1])
+			}
+			value, err := strconv.ParseFloat(valueStr, 64)
+			if err != nil {
+				return nil, nil, fmt.Errorf("query %#v: %w", query, err)
+			}
+			seriesValues[int64(timestamp)] = value
+		}
+		metrics = append(metrics, series.Metric)
+		values = append(values, seriesValues)
+	}
+	return metrics, values, nil
+}
+
+// queryRange runs a Prometheus range query, and returns the labels of each series, along with
+// its values by Unix timestamp. Long ranges are split into multiple queries, each within
+// maxPoints.
+func queryRange(
+	ctx context.Context, client *http.Client, prometheusURL, query string, start, end time.Time, step time.Duration,
+) ([]map[string]string, []map[int64]float64, error) {
+	if step <= 0 {
+		return nil, nil, fmt.Errorf("invalid step: %s", step)
+	}
+	var metrics []map[string]string
+	var values []map[int64]float64
+	indexes := map[string]int{}
+	for chunkStart := start; !chunkStart.After(end); chunkStart = chunkStart.Add(maxPoints * step) {
+		chunkEnd := chunkStart.Add((maxPoints - 1) * step)
+		if chunkEnd.After(end) {
+			chunkEnd = end
+		}
+		chunkMetrics, chunkValues, err := queryRangeChunk(ctx, client, prometheusURL, query, chunkStart, chunkEnd, step)
+		if err != nil {
+			return nil, nil, err
+		}
+		for i, m := range chunkMetrics {
+			key := seriesKey(m)
+			index, ok := indexes[key]
+			if !ok {
+				index = len(metrics)
+				indexes[key] = index
+				metrics = append(metrics, m)
+				values = append(values, map[int64]float64{})
+			}
+			maps.Copy(values[index], chunkValues[i])
+		}
+	}
+	return metrics, values, nil
+}
+
+// FromPrometheus fetches samples between start and end at every step from a Prometheus
+// compatible query endpoint, with targets identified by targetLabel (eg: instance). Failed
+// scrapes of the exporter (up == 0) are samples of an unreachable Hub.
+func FromPrometheus(
+	ctx context.Context,
+	client *http.Client,
+	prometheusURL, targetLabel string,
+	start, end time.Time,
+	step time.Duration,
+) (map[string][]Sample, error) {
+	samplesByTimestamp := map[string]map[int64]*Sample{}
+	sample := func(target string, timestamp int64) *Sample {
+		if samplesByTimestamp[target] == nil {
+			samplesByTimestamp[target] = map[int64]*Sample{}
+		}
+		s, ok := samplesByTimestamp[target][timestamp]
+		if !ok {
+			s = &Sample{Time: time.Unix(timestamp, 0)}
+			samplesByTimestamp[target][timestamp] = s
+		}
+		return s
+	}
+
+	// Samples exist where the state endpoint was scraped, successfully or not
+	metrics, values, err := queryRange(ctx, client, prometheusURL, "virginmedia_hub6_state_up", start, end, step)
+	if err != nil {
+		return nil, err
+	}
+	for i, m := range metrics {
+		for timestamp, value := range values[i] {
+			sample(m[targetLabel], timestamp).Reachable = value == 1
+		}
+	}
+
+	// Failed scrapes of the exporter itself have no samples of its metrics, only of up, which is
+	// queried for the jobs scraping the exporter
+	jobs := map[string]bool{}
+	for _, m := range metrics {
+		if job := m["job"]; job != "" {
+			jobs[job] = true
+		}
+	}
+	if len(jobs) > 0 {
+		jobPatterns := make([]string, 0, len(jobs))
+		for job := range jobs {
+			jobPatterns = append(jobPatterns, regexp.QuoteMeta(job))
+		}
+		sort.Strings(jobPatterns)
+		query := fmt.Sprintf("up{job=~%s} == 0", strconv.Quote(strings.Join(jobPatterns, "|")))
+		metrics, values, err = queryRange(ctx, client, prometheusURL, query, start, end, step)
+		if err != nil {
+			return nil, err
+		}
+		for i, m := range metrics {
+			target := m[targetLabel]
+			// Other targets of the same jobs
+			if samplesByTimestamp[target] == nil {
+				continue
+			}
+			for timestamp := range values[i] {
+				if _, ok := samplesByTimestamp[target][timestamp]; !ok {
+					sample(target, timestamp).Reachable = false
+				}
+			}
+		}
+	}
+
+	metrics, values, err = queryRange(ctx, client, prometheusURL, "virginmedia_hub6_status == 1", start, end, step)
+	if err != nil {
+		return nil, err
+	}
+	for i, m := range metrics {
+		for timestamp := range values[i] {
+			if s, ok := samplesByTimestamp[m[targetLabel]][timestamp]; ok {
+				s.Status = m["status"]
+			}
+		}
+	}
+
+	metrics, values, err = queryRange(ctx, client, prometheusURL, "virginmedia_hub6_uptime_seconds", start, end, step)
+	if err != nil {
+		return nil, err
+	}
+	for i, m := range metrics {
+		for timestamp, value := range values[i] {
+			if s, ok := samplesByTimestamp[m[targetLabel]][timestamp]; ok {
+				s.UptimeSeconds = uint64(value)
+			}
+		}
+	}
+
+	samplesByTarget := map[string][]Sample{}
+	for target, byTimestamp := range samplesByTimestamp {
+		for _, s := range byTimestamp {
+			samplesByTarget[target] = append(samplesByTarget[target], *s)
+		}
+	}
+	return samplesByTarget, nil
+}
@@ -0,0 +1,119 @@
+package report
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFromPrometheus(t *testing.T) {
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(30 * 24 * time.Hour)
+	step := time.Minute
+	// The exporter could not be scraped between these
+	failedStart, failedEnd := start.Add(100*time.Minute).Unix(), start.Add(104*time.Minute).Unix()
+	failed := func(timestamp int64) bool { return timestamp >= failedStart && timestamp <= failedEnd }
+
+	type series struct {
+		Metric map[string]string `json:"metric"`
+		Values [][2]any          `json:"values"`
+	}
+	var mu sync.Mutex
+	queries := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/query_range" {
+			http.NotFound(w, r)
+			return
+		}
+		query := r.URL.Query().Get("query")
+		queryStart, _ := strconv.ParseInt(r.URL.Query().Get("start"), 10, 64)
+		queryEnd, _ := strconv.ParseInt(r.URL.Query().Get("end"), 10, 64)
+		queryStep, _ := strconv.ParseInt(r.URL.Query().Get("step"), 10, 64)
+		// Prometheus rejects queries returning more than 11,000 points per series
+		if points := (queryEnd-queryStart)/queryStep + 1; points > 11000 {
+			t.Errorf("query %s: %d points", query, points)
+		}
+		mu.Lock()
+		queries[query]++
+		mu.Unlock()
+
+		hub := series{Metric: map[string]string{"instance": "hub", "job": "vm"}}
+		other := series{Metric: map[string]string{"instance": "other", "job": "vm"}}
+		for timestamp := queryStart; timestamp <= queryEnd; timestamp += queryStep {
+			var value string
+			switch query {
+			case "virginmedia_hub6_state_up":
+				if !failed(timestamp) {
+					value = "1"
+				}
+			case `up{job=~"vm"} == 0`:
+				if failed(timestamp) {
+					value = "0"
+					other.Values = append(other.Values, [2]any{float64(timestamp), value})
+				}
+			case "virginmedia_hub6_status == 1":
+				hub.Metric = map[string]string{"instance": "hub", "job": "vm", "status": "operational"}
+				if !failed(timestamp) {
+					value = "1"
+				}
+			case "virginmedia_hub6_uptime_seconds":
+				if !failed(timestamp) {
+					value = strconv.FormatInt(timestamp-start.Unix()+1000, 10)
+				}
+			default:
+				t.Errorf("unexpected query: %s", query)
+			}
+			if value != "" {
+				hub.Values = append(hub.Values, [2]any{float64(timestamp), value})
+			}
+		}
+		result := []series{hub}
+		if len(other.Values) > 0 {
+			result = append(result, other)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"status": "success",
+			"data":   map[string]any{"resultType": "matrix", "result": result},
+		})
+	}))
+	defer server.Close()
+
+	samplesByTarget, err := FromPrometheus(context.Background(), server.Client(), server.URL, "instance", start, end, step)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := queries["virginmedia_hub6_state_up"]; n != 4 {
+		t.Errorf("expected the range to be split in 4 queries, got %d", n)
+	}
+	if len(samplesByTarget) != 1 {
+		t.Fatalf("expected only samples of hub, got %d targets", len(samplesByTarget))
+	}
+	samples := samplesByTarget["hub"]
+	if expected := int(end.Sub(start)/step) + 1; len(samples) != expected {
+		t.Fatalf("got %d samples, expected %d", len(samples), expected)
+	}
+	for _, s := range samples {
+		timestamp := s.Time.Unix()
+		var expected Sample
+		if failed(timestamp) {
+			expected = Sample{Time: s.Time}
+		} else {
+			expected = Sample{
+				Time:          s.Time,
+				Reachable:     true,
+				Status:        "operational",
+				UptimeSeconds: uint64(timestamp - start.Unix() + 1000),
+			}
+		}
+		if s != expected {
+			t.Fatalf("got %+v, expected %+v", s, expected)
+		}
+	}
+}
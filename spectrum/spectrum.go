@@ -0,0 +1,99 @@
+package spectrum
+
+import (
+	"math"
+	"sort"
+
+	hub6 "github.com/fornellas/virginmedia_hub6_exporter/hub6"
+)
+
+// SuckoutThresholdDb is how much lower than the average of its neighbours the power of a channel
+// must be, to be considered a suck-out.
+const SuckoutThresholdDb = 3.0
+
+// Analysis of the downstream frequency response, which can indicate physical cabling faults:
+// excessive tilt is typical of long or poor cable runs, suck-outs of faulty splitters or
+// connectors, and ripple of standing waves from impedance mismatches.
+type Analysis struct {
+	// Number of channels used for the analysis
+	Channels int
+	// Slope of the linear regression of power over frequency (dB per 100 MHz)
+	TiltDbPer100Mhz float64
+	// Number of channels which are a local dip vs their neighbours
+	Suckouts int
+	// Largest dip of a channel vs the average of its neighbours (dB)
+	MaxDipDb float64
+	// Peak-to-peak of the power after removing the tilt (dB)
+	RippleDb float64
+	// Root mean square of the power after removing the tilt (dB)
+	RippleRmsDb float64
+}
+
+type point struct {
+	frequencyMhz float64
+	power        float64
+}
+
+// Analyze the frequency response from locked SC-QAM channels (OFDM channels are wide and
+// don't report a frequency). It returns false if there are not enough channels to analyze.
+func Analyze(channels []hub6.DownstreamChannel) (Analysis, bool) {
+	var points []point
+	for _, c := range channels {
+		if c.ChannelType != "sc_qam" || !c.LockStatus || c.Frequency == 0 {
+			continue
+		}
+		points = append(points, point{
+			frequencyMhz: float64(c.Frequency) / 1e6,
+			power:        c.PowerDbmv(),
+		})
+	}
+	if len(points) < 3 {
+		return Analysis{}, false
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].frequencyMhz < points[j].frequencyMhz })
+
+	// Linear regression
+	n := float64(len(points))
+	var sumX, sumY, sumXY, sumXX float64
+	for _, p := range points {
+		sumX += p.frequencyMhz
+		sumY += p.power
+		sumXY += p.frequencyMhz * p.power
+		sumXX += p.frequencyMhz * p.frequencyMhz
+	}
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return Analysis{}, false
+	}
+	slope := (n*sumXY - sumX*sumY) / denominator
+	intercept := (sumY - slope*sumX) / n
+
+	a := Analysis{
+		Channels:        len(points),
+		TiltDbPer100Mhz: slope * 100,
+	}
+
+	// Ripple
+	minResidual := math.Inf(1)
+	maxResidual := math.Inf(-1)
+	var sumSquares float64
+	for _, p := range points {
+		residual := p.power - (intercept + slope*p.frequencyMhz)
+		minResidual = math.Min(minResidual, residual)
+		maxResidual = math.Max(maxResidual, residual)
+		sumSquares += residual * residual
+	}
+	a.RippleDb = maxResidual - minResidual
+	a.RippleRmsDb = math.Sqrt(sumSquares / n)
+
+	// Suck-outs
+	for i := 1; i < len(points)-1; i++ {
+		dip := (points[i-1].power+points[i+1].power)/2 - points[i].power
+		a.MaxDipDb = math.Max(a.MaxDipDb, dip)
+		if dip >= SuckoutThresholdDb {
+			a.Suckouts++
+		}
+	}
+
+	return a, true
+}
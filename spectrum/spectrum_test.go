@@ -0,0 +1,101 @@
+package spectrum
+
+import (
+	"math"
+	"testing"
+
+	hub6 "github.com/fornellas/virginmedia_hub6_exporter/hub6"
+)
+
+func scQam(frequencyMhz uint64, power float64) hub6.DownstreamChannel {
+	return hub6.DownstreamChannel{
+		ChannelType: "sc_qam",
+		Frequency:   frequencyMhz * 1e6,
+		Power:       power,
+		LockStatus:  true,
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	unlocked := scQam(400, -20)
+	unlocked.LockStatus = false
+	ofdm := hub6.DownstreamChannel{ChannelType: "ofdm", Power: 30, LockStatus: true}
+
+	for _, tc := range []struct {
+		name     string
+		channels []hub6.DownstreamChannel
+		ok       bool
+		expected Analysis
+	}{
+		{
+			name:     "not enough channels",
+			channels: []hub6.DownstreamChannel{scQam(100, 5), scQam(200, 5), unlocked, ofdm},
+		},
+		{
+			name:     "same frequency",
+			channels: []hub6.DownstreamChannel{scQam(100, 5), scQam(100, 6), scQam(100, 7)},
+		},
+		{
+			name:     "flat",
+			channels: []hub6.DownstreamChannel{scQam(100, 5), scQam(200, 5), scQam(300, 5), unlocked, ofdm},
+			ok:       true,
+			expected: Analysis{Channels: 3},
+		},
+		{
+			name:     "tilt",
+			channels: []hub6.DownstreamChannel{scQam(400, 3), scQam(100, 0), scQam(300, 2), scQam(200, 1)},
+			ok:       true,
+			expected: Analysis{Channels: 4, TiltDbPer100Mhz: 1},
+		},
+		{
+			name: "suck-out",
+			channels: []hub6.DownstreamChannel{
+				scQam(100, 5), scQam(200, 5), scQam(300, 1), scQam(400, 5), scQam(500, 5),
+			},
+			ok: true,
+			expected: Analysis{
+				Channels:    5,
+				Suckouts:    1,
+				MaxDipDb:    4,
+				RippleDb:    4,
+				RippleRmsDb: 1.6,
+			},
+		},
+		{
+			name: "dip below the threshold",
+			channels: []hub6.DownstreamChannel{
+				scQam(100, 5), scQam(200, 3), scQam(300, 5),
+			},
+			ok: true,
+			expected: Analysis{
+				Channels:    3,
+				MaxDipDb:    2,
+				RippleDb:    2,
+				RippleRmsDb: math.Sqrt(8.0 / 9),
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			a, ok := Analyze(tc.channels)
+			if ok != tc.ok {
+				t.Fatalf("got ok %v, expected %v", ok, tc.ok)
+			}
+			if a.Channels != tc.expected.Channels || a.Suckouts != tc.expected.Suckouts {
+				t.Errorf("got %+v, expected %+v", a, tc.expected)
+			}
+			for _, f := range []struct {
+				name          string
+				got, expected float64
+			}{
+				{"TiltDbPer100Mhz", a.TiltDbPer100Mhz, tc.expected.TiltDbPer100Mhz},
+				{"MaxDipDb", a.MaxDipDb, tc.expected.MaxDipDb},
+				{"RippleDb", a.RippleDb, tc.expected.RippleDb},
+				{"RippleRmsDb", a.RippleRmsDb, tc.expected.RippleRmsDb},
+			} {
+				if math.Abs(f.got-f.expected) > 1e-9 {
+					t.Errorf("%s: got %v, expected %v", f.name, f.got, f.expected)
+				}
+			}
+		})
+	}
+}
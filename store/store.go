@@ -0,0 +1,176 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fornellas/virginmedia_hub6_exporter/exporter"
+)
+
+const (
+	fileName = "state.json"
+	version  = 1
+)
+
+type file struct {
+	Version int `json:"version"`
+	// State by target
+	Targets map[string]*exporter.State `json:"targets"`
+}
+
+// Store persists the state of HubExporters to a JSON file, so derived metrics (eg: reboot
+// counts) survive exporter restarts.
+type Store struct {
+	path string
+
+	mu           sync.Mutex
+	hubExporters map[string]*exporter.HubExporter
+	// State loaded from disk, for targets without a registered HubExporter yet
+	loaded map[string]*exporter.State
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// Open loads the state file from dir, and starts flushing it at every flushInterval. A
+// corrupted state file is moved aside, and the store starts empty.
+func Open(dir string, flushInterval time.Duration) (*Store, error) {
+	if flushInterval <= 0 {
+		return nil, fmt.Errorf("flush interval must be positive, got %s", flushInterval)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	s := &Store{
+		path:         filepath.Join(dir, fileName),
+		hubExporters: map[string]*exporter.HubExporter{},
+		loaded:       map[string]*exporter.State{},
+		stop:         make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	go s.run(flushInterval)
+
+	return s, nil
+}
+
+func (s *Store) load() error {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	var f file
+	err = json.Unmarshal(data, &f)
+	if err == nil && f.Version != version {
+		err = fmt.Errorf("unsupported version %d", f.Version)
+	}
+	if err != nil {
+		corruptPath := fmt.Sprintf("%s.corrupt-%d", s.path, time.Now().Unix())
+		slog.Warn("Corrupted state file, starting with empty state", "path", s.path, "moved_to", corruptPath, "err", err)
+		return os.Rename(s.path, corruptPath)
+	}
+
+	if f.Targets != nil {
+		s.loaded = f.Targets
+	}
+	slog.Info("Loaded state", "path", s.path, "targets", len(s.loaded))
+	return nil
+}
+
+// Register adds a HubExporter for target to the store, restoring any state previously saved
+// for it.
+func (s *Store) Register(target string, e *exporter.HubExporter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if state, ok := s.loaded[target]; ok && state != nil {
+		e.RestoreState(state)
+		delete(s.loaded, target)
+	}
+	s.hubExporters[target] = e
+}
+
+// Unregister removes the HubExporter for target from the store, discarding its state.
+func (s *Store) Unregister(target string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.hubExporters, target)
+}
+
+// Flush atomically writes the state of all registered HubExporters to disk.
+func (s *Store) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f := file{
+		Version: version,
+		Targets: map[string]*exporter.State{},
+	}
+	// Keep state of targets which were not probed since the exporter started
+	for target, state := range s.loaded {
+		f.Targets[target] = state
+	}
+	for target, e := range s.hubExporters {
+		f.Targets[target] = e.State()
+	}
+
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), fileName+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+func (s *Store) run(flushInterval time.Duration) {
+	defer close(s.done)
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			if err := s.Flush(); err != nil {
+				slog.Error("Failed to flush state", "path", s.path, "err", err)
+			}
+		}
+	}
+}
+
+// Close stops periodic flushing, and flushes one last time.
+func (s *Store) Close() error {
+	close(s.stop)
+	<-s.done
+	return s.Flush()
+}
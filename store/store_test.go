@@ -0,0 +1,159 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fornellas/virginmedia_hub6_exporter/exporter"
+)
+
+func newHubExporter(reboots uint64) *exporter.HubExporter {
+	e := exporter.NewHubExporter("192.168.0.1", time.Second)
+	e.RestoreState(&exporter.State{
+		Reboots: exporter.RebootState{BootTime: time.Unix(1700000000, 0).UTC(), Reboots: reboots},
+	})
+	return e
+}
+
+func TestOpenInvalidFlushInterval(t *testing.T) {
+	for _, flushInterval := range []time.Duration{0, -time.Second} {
+		if _, err := Open(t.TempDir(), flushInterval); err == nil {
+			t.Errorf("%s: expected error", flushInterval)
+		}
+	}
+}
+
+func TestStoreRestart(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := Open(dir, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Register("hub1", newHubExporter(3))
+	s.Register("hub2", newHubExporter(5))
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Restart, with only hub1 probed
+	s, err = Open(dir, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub1 := exporter.NewHubExporter("192.168.0.1", time.Second)
+	s.Register("hub1", hub1)
+	if expected := newHubExporter(3).State(); !reflect.DeepEqual(hub1.State(), expected) {
+		t.Errorf("hub1: got %+v, expected %+v", hub1.State(), expected)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// State of hub2 is kept, although it was not probed
+	s, err = Open(dir, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	hub2 := exporter.NewHubExporter("192.168.0.1", time.Second)
+	s.Register("hub2", hub2)
+	if expected := newHubExporter(5).State(); !reflect.DeepEqual(hub2.State(), expected) {
+		t.Errorf("hub2: got %+v, expected %+v", hub2.State(), expected)
+	}
+}
+
+func TestStoreUnregister(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := Open(dir, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Register("hub1", newHubExporter(3))
+	s.Unregister("hub1")
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = Open(dir, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if len(s.loaded) != 0 {
+		t.Errorf("got %v, expected no state", s.loaded)
+	}
+}
+
+func TestStorePeriodicFlush(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := Open(dir, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	s.Register("hub1", newHubExporter(3))
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat(filepath.Join(dir, fileName)); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("state file not flushed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestStoreCorrupted(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		data string
+	}{
+		{name: "invalid JSON", data: "{"},
+		{name: "unsupported version", data: `{"version": 2, "targets": {}}`},
+	} {
+		dir := t.TempDir()
+		path := filepath.Join(dir, fileName)
+		if err := os.WriteFile(path, []byte(tc.data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		s, err := Open(dir, time.Hour)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if len(s.loaded) != 0 {
+			t.Errorf("%s: got %v, expected no state", tc.name, s.loaded)
+		}
+		if err := s.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		// The corrupted file is moved aside, untouched
+		matches, err := filepath.Glob(path + ".corrupt-*")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(matches) != 1 {
+			t.Fatalf("%s: got %v, expected one corrupted file", tc.name, matches)
+		}
+		if suffix := strings.TrimPrefix(matches[0], path+".corrupt-"); strings.Trim(suffix, "0123456789") != "" {
+			t.Errorf("%s: got %s, expected a unix time suffix", tc.name, matches[0])
+		}
+		data, err := os.ReadFile(matches[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != tc.data {
+			t.Errorf("%s: got %q, expected %q", tc.name, data, tc.data)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package syslog
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fornellas/virginmedia_hub6_exporter/events"
+)
+
+// Facility of syslog messages.
+type Facility int
+
+var facilities = map[string]Facility{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5, "lpr": 6, "news": 7,
+	"uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19,
+	"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// ParseFacility returns the Facility named name, eg: "daemon" or "local0".
+func ParseFacility(name string) (Facility, error) {
+	facility, ok := facilities[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown syslog facility: %#v", name)
+	}
+	return facility, nil
+}
+
+const appName = "virginmedia_hub6_exporter"
+
+// sdId is the id of the structured data element with event params. 32473 is the private
+// enterprise number reserved for documentation (RFC 5612).
+const sdId = "hub6@32473"
+
+// queueSize is the number of events which can be pending delivery; events are dropped after
+// this.
+const queueSize = 1000
+
+// Writer is an events.Sink which forwards events as RFC 5424 syslog messages. Events are
+// delivered in the background, reconnecting as needed.
+type Writer struct {
+	// udp, tcp, tls, unix or unixgram
+	network   string
+	address   string
+	tlsConfig *tls.Config
+	facility  Facility
+	timeout   time.Duration
+	hostname  string
+
+	// mu guards sends to queue against closing it
+	mu     sync.Mutex
+	queue  chan events.Event
+	closed bool
+	done   chan struct{}
+	// Only used by the background goroutine
+	conn net.Conn
+}
+
+// NewWriter creates a new Writer which sends messages to address over network, which is one of
+// udp, tcp, tls, unix (stream socket) or unixgram (datagram socket, eg: /dev/log). Close must
+// be called when done.
+func NewWriter(network, address string) (*Writer, error) {
+	switch network {
+	case "udp", "tcp", "tls", "unix", "unixgram":
+	default:
+		return nil, fmt.Errorf("unsupported syslog network: %#v", network)
+	}
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+	w := &Writer{
+		network:   network,
+		address:   address,
+		tlsConfig: &tls.Config{},
+		facility:  facilities["daemon"],
+		timeout:   10 * time.Second,
+		hostname:  hostname,
+		queue:     make(chan events.Event, queueSize),
+		done:      make(chan struct{}),
+	}
+	go w.run()
+	return w, nil
+}
+
+// TLSConfig sets the TLS configuration used with the tls network.
+func (w *Writer) TLSConfig(c *tls.Config) *Writer {
+	w.tlsConfig = c
+	return w
+}
+
+// Facility sets the facility of messages, instead of daemon.
+func (w *Writer) Facility(f Facility) *Writer {
+	w.facility = f
+	return w
+}
+
+// Send queues e for delivery. Events sent after Close are dropped.
+func (w *Writer) Send(e events.Event) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return
+	}
+	select {
+	case w.queue <- e:
+	default:
+		slog.Warn("Syslog queue full, dropping event", "address", w.address, "type", e.Type)
+	}
+}
+
+// Close delivers queued events, and closes the connection.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.queue)
+	}
+	w.mu.Unlock()
+	<-w.done
+	return nil
+}
+
+func (w *Writer) run() {
+	defer close(w.done)
+	for e := range w.queue {
+		if err := w.write(Format(e, w.facility, w.hostname)); err != nil {
+			slog.Warn("Failed to send event to syslog", "address", w.address, "type", e.Type, "err", err)
+		}
+	}
+	if w.conn != nil {
+		w.conn.Close()
+	}
+}
+
+func (w *Writer) dial() (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: w.timeout}
+	if w.network == "tls" {
+		return tls.DialWithDialer(dialer, "tcp", w.address, w.tlsConfig)
+	}
+	return dialer.Dial(w.network, w.address)
+}
+
+// frame returns msg framed for the network: octet counting for tcp / tls (RFC 6587 / RFC 5425),
+// a trailing newline for unix stream sockets, and as is for datagrams.
+func (w *Writer) frame(msg string) string {
+	switch w.network {
+	case "tcp", "tls":
+		return fmt.Sprintf("%d %s", len(msg), msg)
+	case "unix":
+		return msg + "\n"
+	default:
+		return msg
+	}
+}
+
+// write sends msg, reconnecting and retrying once on failure.
+func (w *Writer) write(msg string) error {
+	framed := w.frame(msg)
+	var errs []error
+	for range 2 {
+		if w.conn == nil {
+			conn, err := w.dial()
+			if err != nil {
+				return errors.Join(append(errs, err)...)
+			}
+			w.conn = conn
+		}
+		if err := w.conn.SetWriteDeadline(time.Now().Add(w.timeout)); err != nil {
+			errs = append(errs, err)
+		} else if _, err := w.conn.Write([]byte(framed)); err != nil {
+			errs = append(errs, err)
+		} else {
+			return nil
+		}
+		w.conn.Close()
+		w.conn = nil
+	}
+	return errors.Join(errs...)
+}
+
+var sdEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+// sdName returns name usable as a structured data param name: printable ASCII, without
+// '=', ' ', ']' and '"', up to 32 characters.
+func sdName(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		if r <= 32 || r >= 127 || r == '=' || r == ']' || r == '"' {
+			r = '_'
+		}
+		b.WriteRune(r)
+	}
+	s := b.String()
+	if len(s) > 32 {
+		s = s[:32]
+	}
+	return s
+}
+
+// Format returns e as an RFC 5424 syslog message, with the target and event params as
+// structured data.
+func Format(e events.Event, facility Facility, hostname string) string {
+	var sd strings.Builder
+	fmt.Fprintf(&sd, `[%s target="%s"`, sdId, sdEscaper.Replace(e.Target))
+	for _, p := range e.Params {
+		if p.Value == "" {
+			continue
+		}
+		fmt.Fprintf(&sd, ` %s="%s"`, sdName(p.Name), sdEscaper.Replace(p.Value))
+	}
+	sd.WriteString("]")
+
+	return fmt.Sprintf("<%d>1 %s %s %s %d %s %s %s",
+		int(facility)*8+int(e.Severity),
+		e.Time.UTC().Format("2006-01-02T15:04:05.000000Z07:00"),
+		hostname,
+		appName,
+		os.Getpid(),
+		e.Type,
+		sd.String(),
+		e.Message,
+	)
+}
@@ -0,0 +1,45 @@
+package syslog
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fornellas/virginmedia_hub6_exporter/events"
+)
+
+func TestWriterSendAfterClose(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	w, err := NewWriter("udp", conn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Send(events.Event{Time: time.Now(), Target: "192.168.0.1", Type: "reboot", Message: "Hub rebooted"})
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := conn.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 2048)
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("event not delivered: %v", err)
+	}
+	if msg := string(buf[:n]); !strings.HasSuffix(msg, "Hub rebooted") {
+		t.Errorf("unexpected message: %s", msg)
+	}
+
+	// Events sent after Close, eg: by a scrape racing with shutdown, are dropped
+	w.Send(events.Event{Time: time.Now(), Type: "reboot"})
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,307 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/fornellas/virginmedia_hub6_exporter/events"
+)
+
+// Format of webhook requests.
+type Format string
+
+const (
+	// JSON with all event fields
+	Generic Format = "generic"
+	// Slack compatible incoming webhook
+	Slack Format = "slack"
+	// ntfy topic URL
+	Ntfy Format = "ntfy"
+	// Gotify message URL, eg: https://gotify.example.com/message?token=...
+	Gotify Format = "gotify"
+)
+
+// ParseFormat returns the Format named name.
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(name); f {
+	case Generic, Slack, Ntfy, Gotify:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unknown webhook format: %#v", name)
+	}
+}
+
+// Notify returns whether e is notified: reboots, status, network access and provisioning
+// changes, and channels losing lock.
+func Notify(e events.Event) bool {
+	switch e.Type {
+	case events.Reboot, events.StatusChanged, events.AccessAllowedChanged, events.LockLost,
+		events.ProvisioningChanged:
+		return true
+	default:
+		return false
+	}
+}
+
+// queueSize is the number of events which can be pending delivery; the oldest events are
+// dropped after this.
+const queueSize = 1000
+
+// Backoff between delivery attempts, and how long Close waits for pending events to be
+// delivered. Variables, so tests can shorten them.
+var (
+	minBackoff   = time.Second
+	maxBackoff   = 5 * time.Minute
+	closeTimeout = 10 * time.Second
+)
+
+// Notifier is an events.Sink which POSTs notified events (see Notify) to a webhook. Events are
+// queued, and delivered in order in the background, retrying with exponential backoff, as when
+// the Hub is down, the webhook is often unreachable too. Only network errors, and responses
+// which may succeed later (5xx, 408 and 429) are retried; events failing otherwise are
+// dropped, as retrying them would block all events queued after them.
+type Notifier struct {
+	url    string
+	format Format
+	client *http.Client
+
+	mu     sync.Mutex
+	queue  []events.Event
+	wake   chan struct{}
+	closed bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewNotifier creates a new Notifier which POSTs to url in format. timeout is applied to each
+// HTTP request. Close must be called when done.
+func NewNotifier(url string, format Format, timeout time.Duration) *Notifier {
+	ctx, cancel := context.WithCancel(context.Background())
+	n := &Notifier{
+		url:    url,
+		format: format,
+		client: &http.Client{Timeout: timeout},
+		wake:   make(chan struct{}, 1),
+		ctx:    ctx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go n.run()
+	return n
+}
+
+// Send queues e for delivery, if it is notified.
+func (n *Notifier) Send(e events.Event) {
+	if !Notify(e) {
+		return
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.closed {
+		return
+	}
+	if len(n.queue) >= queueSize {
+		slog.Warn("Webhook queue full, dropping oldest event", "format", n.format, "type", n.queue[0].Type)
+		n.queue = n.queue[1:]
+	}
+	n.queue = append(n.queue, e)
+	select {
+	case n.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Close waits for pending events to be delivered, up to a timeout, and stops delivery.
+func (n *Notifier) Close() error {
+	n.mu.Lock()
+	n.closed = true
+	pending := len(n.queue)
+	n.mu.Unlock()
+	select {
+	case n.wake <- struct{}{}:
+	default:
+	}
+
+	select {
+	case <-n.done:
+	case <-time.After(closeTimeout):
+		n.cancel()
+		<-n.done
+	}
+	n.cancel()
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if len(n.queue) > 0 {
+		return fmt.Errorf("webhook: %d of %d pending events not delivered", len(n.queue), pending)
+	}
+	return nil
+}
+
+// next returns the oldest queued event, waiting for one. It returns false when closed and
+// there are no more events, or when the context is canceled.
+func (n *Notifier) next() (events.Event, bool) {
+	for {
+		n.mu.Lock()
+		if len(n.queue) > 0 {
+			e := n.queue[0]
+			n.mu.Unlock()
+			return e, true
+		}
+		closed := n.closed
+		n.mu.Unlock()
+		if closed {
+			return events.Event{}, false
+		}
+		select {
+		case <-n.wake:
+		case <-n.ctx.Done():
+			return events.Event{}, false
+		}
+	}
+}
+
+func (n *Notifier) run() {
+	defer close(n.done)
+	backoff := minBackoff
+	for {
+		e, ok := n.next()
+		if !ok {
+			return
+		}
+		if retry, err := n.post(e); err != nil {
+			if retry {
+				slog.Warn("Failed to send webhook, retrying", "format", n.format, "type", e.Type, "retry_in", backoff, "err", err)
+				select {
+				case <-time.After(backoff):
+				case <-n.ctx.Done():
+					return
+				}
+				backoff = min(backoff*2, maxBackoff)
+				continue
+			}
+			slog.Error("Failed to send webhook, dropping event", "format", n.format, "type", e.Type, "err", err)
+		}
+		backoff = minBackoff
+		n.mu.Lock()
+		n.queue = n.queue[1:]
+		n.mu.Unlock()
+	}
+}
+
+// retryStatus returns whether a request failing with statusCode may succeed later.
+func retryStatus(statusCode int) bool {
+	return statusCode >= 500 || statusCode == http.StatusRequestTimeout || statusCode == http.StatusTooManyRequests
+}
+
+// post delivers e, returning whether it should be retried on error.
+func (n *Notifier) post(e events.Event) (bool, error) {
+	body, headers, err := Request(e, n.format)
+	if err != nil {
+		return false, err
+	}
+	req, err := http.NewRequestWithContext(n.ctx, "POST", n.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return retryStatus(resp.StatusCode), fmt.Errorf("unexpected status %d from webhook", resp.StatusCode)
+	}
+	return false, nil
+}
+
+// GenericEvent is the body of Generic webhook requests.
+type GenericEvent struct {
+	Time     time.Time         `json:"time"`
+	Target   string            `json:"target"`
+	Type     string            `json:"type"`
+	Severity string            `json:"severity"`
+	Message  string            `json:"message"`
+	Params   map[string]string `json:"params"`
+}
+
+// ntfyPriority maps severities to ntfy priorities (1 = min, 5 = max).
+func ntfyPriority(s events.Severity) string {
+	switch {
+	case s <= events.Critical:
+		return "5"
+	case s <= events.Warning:
+		return "4"
+	case s == events.Notice:
+		return "3"
+	default:
+		return "2"
+	}
+}
+
+// gotifyPriority maps severities to Gotify priorities (0 = silent, 10 = highest).
+func gotifyPriority(s events.Severity) int {
+	switch {
+	case s <= events.Critical:
+		return 10
+	case s <= events.Warning:
+		return 8
+	case s == events.Notice:
+		return 5
+	default:
+		return 2
+	}
+}
+
+// Request returns the body and headers of the webhook request for e in format.
+func Request(e events.Event, format Format) ([]byte, map[string]string, error) {
+	title := fmt.Sprintf("Virgin Media Hub %s: %s", e.Target, e.Type)
+	jsonHeaders := map[string]string{"Content-Type": "application/json"}
+	switch format {
+	case Generic:
+		params := map[string]string{}
+		for _, p := range e.Params {
+			params[p.Name] = p.Value
+		}
+		body, err := json.Marshal(GenericEvent{
+			Time:     e.Time,
+			Target:   e.Target,
+			Type:     e.Type,
+			Severity: e.Severity.String(),
+			Message:  e.Message,
+			Params:   params,
+		})
+		return body, jsonHeaders, err
+	case Slack:
+		body, err := json.Marshal(map[string]string{
+			"text": fmt.Sprintf("*%s*\n%s", title, e.Message),
+		})
+		return body, jsonHeaders, err
+	case Ntfy:
+		return []byte(e.Message), map[string]string{
+			"Title":    title,
+			"Priority": ntfyPriority(e.Severity),
+			"Tags":     e.Type,
+		}, nil
+	case Gotify:
+		body, err := json.Marshal(map[string]any{
+			"title":    title,
+			"message":  e.Message,
+			"priority": gotifyPriority(e.Severity),
+		})
+		return body, jsonHeaders, err
+	default:
+		return nil, nil, fmt.Errorf("unknown webhook format: %#v", format)
+	}
+}
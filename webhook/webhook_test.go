@@ -0,0 +1,281 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fornellas/virginmedia_hub6_exporter/events"
+)
+
+// shortenTimeouts shortens backoff and Close timeouts for the duration of the test.
+func shortenTimeouts(t *testing.T) {
+	t.Helper()
+	savedMinBackoff, savedMaxBackoff, savedCloseTimeout := minBackoff, maxBackoff, closeTimeout
+	minBackoff, maxBackoff, closeTimeout = 20*time.Millisecond, 40*time.Millisecond, 200*time.Millisecond
+	t.Cleanup(func() {
+		minBackoff, maxBackoff, closeTimeout = savedMinBackoff, savedMaxBackoff, savedCloseTimeout
+	})
+}
+
+type request struct {
+	time    time.Time
+	message string
+}
+
+// webhookServer records the message of Generic requests, and replies with statuses, in order,
+// then 200.
+type webhookServer struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []request
+}
+
+func (s *webhookServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var e GenericEvent
+	json.NewDecoder(r.Body).Decode(&e)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, request{time: time.Now(), message: e.Message})
+	status := http.StatusOK
+	if len(s.statuses) > 0 {
+		status = s.statuses[0]
+		s.statuses = s.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func (s *webhookServer) messages() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var messages []string
+	for _, r := range s.requests {
+		messages = append(messages, r.message)
+	}
+	return messages
+}
+
+func newWebhookServer(t *testing.T, statuses ...int) (*webhookServer, string) {
+	t.Helper()
+	s := &webhookServer{statuses: statuses}
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+	return s, server.URL
+}
+
+func reboot(message string) events.Event {
+	return events.Event{
+		Time:     time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Target:   "192.168.0.1",
+		Type:     events.Reboot,
+		Severity: events.Warning,
+		Message:  message,
+	}
+}
+
+func TestNotifierOrder(t *testing.T) {
+	shortenTimeouts(t)
+	server, url := newWebhookServer(t)
+
+	n := NewNotifier(url, Generic, time.Second)
+	n.Send(reboot("1"))
+	// Not notified
+	n.Send(events.Event{Type: events.LockAcquired, Message: "lock acquired"})
+	n.Send(reboot("2"))
+	n.Send(reboot("3"))
+	if err := n.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// Closed
+	n.Send(reboot("4"))
+
+	if messages, expected := server.messages(), []string{"1", "2", "3"}; !reflect.DeepEqual(messages, expected) {
+		t.Errorf("got %v, expected %v", messages, expected)
+	}
+}
+
+func TestNotifierRetry(t *testing.T) {
+	shortenTimeouts(t)
+	server, url := newWebhookServer(t,
+		http.StatusServiceUnavailable,
+		http.StatusTooManyRequests,
+		http.StatusRequestTimeout,
+		http.StatusInternalServerError,
+	)
+
+	n := NewNotifier(url, Generic, time.Second)
+	n.Send(reboot("1"))
+	n.Send(reboot("2"))
+	if err := n.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Later events wait for the failing one
+	if messages, expected := server.messages(), []string{"1", "1", "1", "1", "1", "2"}; !reflect.DeepEqual(messages, expected) {
+		t.Fatalf("got %v, expected %v", messages, expected)
+	}
+	// Exponential backoff, up to the maximum, and reset after a delivery
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	for i, backoff := range []time.Duration{minBackoff, 2 * minBackoff, maxBackoff, maxBackoff} {
+		if wait := server.requests[i+1].time.Sub(server.requests[i].time); wait < backoff {
+			t.Errorf("attempt %d: waited %s, expected at least %s", i+2, wait, backoff)
+		}
+	}
+	if wait := server.requests[5].time.Sub(server.requests[4].time); wait >= minBackoff {
+		t.Errorf("waited %s after a delivery, expected no backoff", wait)
+	}
+}
+
+func TestNotifierDrop(t *testing.T) {
+	shortenTimeouts(t)
+	server, url := newWebhookServer(t, http.StatusBadRequest, http.StatusNotFound)
+
+	n := NewNotifier(url, Generic, time.Second)
+	n.Send(reboot("1"))
+	n.Send(reboot("2"))
+	n.Send(reboot("3"))
+	if err := n.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if messages, expected := server.messages(), []string{"1", "2", "3"}; !reflect.DeepEqual(messages, expected) {
+		t.Errorf("got %v, expected %v", messages, expected)
+	}
+}
+
+func TestNotifierNetworkError(t *testing.T) {
+	shortenTimeouts(t)
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	n := NewNotifier(url, Generic, time.Second)
+	defer n.Close()
+	if retry, err := n.post(reboot("1")); err == nil || !retry {
+		t.Errorf("got %v %v, expected a retried error", retry, err)
+	}
+}
+
+func TestNotifierCloseTimeout(t *testing.T) {
+	shortenTimeouts(t)
+	statuses := make([]int, 1000)
+	for i := range statuses {
+		statuses[i] = http.StatusServiceUnavailable
+	}
+	_, url := newWebhookServer(t, statuses...)
+
+	n := NewNotifier(url, Generic, time.Second)
+	n.Send(reboot("1"))
+	n.Send(reboot("2"))
+	start := time.Now()
+	err := n.Close()
+	if err == nil {
+		t.Error("expected error")
+	}
+	if elapsed := time.Since(start); elapsed < closeTimeout || elapsed > closeTimeout+time.Second {
+		t.Errorf("closed in %s, expected about %s", elapsed, closeTimeout)
+	}
+}
+
+func TestRequest(t *testing.T) {
+	e := events.Event{
+		Time:     time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Target:   "192.168.0.1",
+		Type:     events.LockLost,
+		Severity: events.Critical,
+		Message:  "Downstream channel 3 lost lock",
+		Params:   []events.Param{{Name: "channel_id", Value: "3"}},
+	}
+	jsonHeaders := map[string]string{"Content-Type": "application/json"}
+	for _, tc := range []struct {
+		format          Format
+		expectedBody    string
+		expectedHeaders map[string]string
+	}{
+		{
+			format:          Generic,
+			expectedBody:    `{"time":"2026-01-02T03:04:05Z","target":"192.168.0.1","type":"lock_lost","severity":"critical","message":"Downstream channel 3 lost lock","params":{"channel_id":"3"}}`,
+			expectedHeaders: jsonHeaders,
+		},
+		{
+			format:          Slack,
+			expectedBody:    `{"text":"*Virgin Media Hub 192.168.0.1: lock_lost*\nDownstream channel 3 lost lock"}`,
+			expectedHeaders: jsonHeaders,
+		},
+		{
+			format:       Ntfy,
+			expectedBody: "Downstream channel 3 lost lock",
+			expectedHeaders: map[string]string{
+				"Title":    "Virgin Media Hub 192.168.0.1: lock_lost",
+				"Priority": "5",
+				"Tags":     "lock_lost",
+			},
+		},
+		{
+			format:          Gotify,
+			expectedBody:    `{"message":"Downstream channel 3 lost lock","priority":10,"title":"Virgin Media Hub 192.168.0.1: lock_lost"}`,
+			expectedHeaders: jsonHeaders,
+		},
+	} {
+		body, headers, err := Request(e, tc.format)
+		if err != nil {
+			t.Errorf("%s: %v", tc.format, err)
+			continue
+		}
+		if string(body) != tc.expectedBody {
+			t.Errorf("%s: got body %s, expected %s", tc.format, body, tc.expectedBody)
+		}
+		if !reflect.DeepEqual(headers, tc.expectedHeaders) {
+			t.Errorf("%s: got headers %v, expected %v", tc.format, headers, tc.expectedHeaders)
+		}
+	}
+
+	if _, _, err := Request(e, Format("unknown")); err == nil {
+		t.Error("unknown: expected error")
+	}
+}
+
+func TestNotifierFormats(t *testing.T) {
+	shortenTimeouts(t)
+	for _, format := range []Format{Generic, Slack, Ntfy, Gotify} {
+		var mu sync.Mutex
+		var body []byte
+		var header http.Header
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			body, _ = io.ReadAll(r.Body)
+			header = r.Header
+		}))
+
+		e := reboot("Hub rebooted")
+		n := NewNotifier(server.URL, format, time.Second)
+		n.Send(e)
+		if err := n.Close(); err != nil {
+			t.Fatal(err)
+		}
+		server.Close()
+
+		expectedBody, expectedHeaders, err := Request(e, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mu.Lock()
+		if string(body) != string(expectedBody) {
+			t.Errorf("%s: got body %s, expected %s", format, body, expectedBody)
+		}
+		for name, value := range expectedHeaders {
+			if header.Get(name) != value {
+				t.Errorf("%s: got header %s %q, expected %q", format, name, header.Get(name), value)
+			}
+		}
+		mu.Unlock()
+	}
+}